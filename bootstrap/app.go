@@ -1,61 +1,154 @@
 package bootstrap
 
 import (
+	"context"
 	"os"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
 	"github.com/google/uuid"
 	"github.com/lhlyu/kratos-easy/constants"
+	"github.com/lhlyu/kratos-easy/healthx"
+	"github.com/lhlyu/kratos-easy/metricsx"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
-// NewApp 创建一个 Kratos 应用实例，带默认 metadata 和日志输出
-func NewApp(logger log.Logger, servers ...transport.Server) *kratos.App {
-	// 生成唯一实例 ID
-	id := uuid.NewString()
+// instanceId 当前进程的唯一实例 ID，应用与链路追踪共用
+var instanceId = uuid.NewString()
 
-	// 获取应用信息
-	name := os.Getenv(constants.ProjectName)
-	if name == "" {
-		name = "unknown-service"
+// appInfo 应用信息，来源于环境变量
+type appInfo struct {
+	id      string
+	name    string
+	ref     string
+	version string
+	env     string
+}
+
+// getAppInfo 获取应用信息，缺失时使用默认值
+func getAppInfo() appInfo {
+	return appInfo{
+		id:      instanceId,
+		name:    getEnvOr(constants.ProjectName, "unknown-service"),
+		ref:     getEnvOr(constants.ProjectRef, "unknown-ref"),
+		version: getEnvOr(constants.ProjectSha, "unknown-version"),
+		env:     getEnvOr(constants.AppEnv, "unknown-env"),
 	}
-	ref := os.Getenv(constants.ProjectRef)
-	if ref == "" {
-		ref = "unknown-ref"
+}
+
+// getEnvOr 获取环境变量，为空时返回默认值
+func getEnvOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
-	version := os.Getenv(constants.ProjectSha)
-	if version == "" {
-		version = "unknown-version"
+	return def
+}
+
+// NewApp 创建一个 Kratos 应用实例，带默认 metadata 和日志输出
+//
+// 通过 Option 注册的生命周期钩子和优雅关闭时间也会在这里生效。
+// 默认在 HTTP Server 上挂载 /healthz、/readyz，使用 WithMetrics 时还会挂载 /metrics；
+// 在 gRPC Server 上注册 grpc.health.v1，gRPC Server 需要使用 grpc.CustomHealth() 创建，否则保留 kratos 自带的健康检查。
+func NewApp(logger log.Logger, servers ...transport.Server) *kratos.App {
+	// 获取应用信息
+	info := getAppInfo()
+
+	if globalOption.enableHealth {
+		registerHealth(logger, servers)
 	}
-	env := os.Getenv(constants.AppEnv)
-	if env == "" {
-		env = "unknown-env"
+	if globalOption.enableMetrics {
+		registerMetrics(servers)
 	}
 
 	// 构造 metadata
 	md := map[string]string{
-		"env": env,
+		"env": info.env,
 	}
 
 	// 启动日志
 	_ = logger.Log(
 		log.LevelInfo,
 		"msg", "starting service",
-		"service.name", name,
-		"service.id", id,
-		"service.ref", ref,
-		"service.version", version,
-		"env", env,
+		"service.name", info.name,
+		"service.id", info.id,
+		"service.ref", info.ref,
+		"service.version", info.version,
+		"env", info.env,
 	)
 
-	// 返回 Kratos App
-	return kratos.New(
-		kratos.ID(id),
-		kratos.Name(name),
-		kratos.Version(version),
+	opts := []kratos.Option{
+		kratos.ID(info.id),
+		kratos.Name(info.name),
+		kratos.Version(info.version),
 		kratos.Metadata(md),
-		kratos.Logger(logger),
 		kratos.Server(servers...),
-	)
+		kratos.StopTimeout(globalOption.shutdownTimeout),
+	}
+
+	// kratos.Logger 只用于覆盖全局日志器，WithDisableGlobal 时不传入
+	if globalOption.setGlobal {
+		opts = append(opts, kratos.Logger(logger))
+	}
+
+	// 开始关闭时立即标记为未就绪，让负载均衡摘除流量
+	if globalOption.enableHealth {
+		opts = append(opts, kratos.BeforeStop(func(context.Context) error {
+			healthx.Default().Shutdown()
+			return nil
+		}))
+	}
+
+	// 注册生命周期钩子
+	for _, h := range globalOption.beforeStart {
+		opts = append(opts, kratos.BeforeStart(h))
+	}
+	for _, h := range globalOption.afterStart {
+		opts = append(opts, kratos.AfterStart(h))
+	}
+	for _, h := range globalOption.beforeStop {
+		opts = append(opts, kratos.BeforeStop(h))
+	}
+	for _, h := range globalOption.afterStop {
+		opts = append(opts, kratos.AfterStop(h))
+	}
+
+	// 返回 Kratos App
+	return kratos.New(opts...)
+}
+
+// registerHealth 在服务上注册健康检查
+//
+// gRPC Server 未使用 grpc.CustomHealth() 创建时，kratos 自带的健康检查已占用 grpc.health.v1，
+// 此时只记录警告并跳过 gRPC 注册，HTTP 检查不受影响。
+func registerHealth(logger log.Logger, servers []transport.Server) {
+	registry := healthx.Default()
+	registry.Resume()
+
+	for _, srv := range servers {
+		switch s := srv.(type) {
+		case *http.Server:
+			s.Handle(healthx.LivenessPath, registry.LivenessHandler())
+			s.Handle(healthx.ReadinessPath, registry.ReadinessHandler())
+		case *grpc.Server:
+			if _, ok := s.GetServiceInfo()[grpc_health_v1.Health_ServiceDesc.ServiceName]; ok {
+				_ = logger.Log(log.LevelWarn,
+					"msg", "grpc health service already registered, create the server with grpc.CustomHealth() to serve healthx status",
+				)
+				continue
+			}
+			grpc_health_v1.RegisterHealthServer(s, registry.NewGrpcServer())
+		}
+	}
+}
+
+// registerMetrics 在 HTTP Server 上挂载指标
+func registerMetrics(servers []transport.Server) {
+	for _, srv := range servers {
+		if s, ok := srv.(*http.Server); ok {
+			s.Handle(metricsx.MetricsPath, metricsx.Handler())
+		}
+	}
 }
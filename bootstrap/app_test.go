@@ -0,0 +1,94 @@
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/lhlyu/kratos-easy/healthx"
+	ggrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewAppGrpcHealth(t *testing.T) {
+	enableMetrics, beforeStop, timeout := globalOption.enableMetrics, globalOption.beforeStop, globalOption.shutdownTimeout
+	setGlobal := globalOption.setGlobal
+	t.Cleanup(func() {
+		globalOption.enableMetrics, globalOption.beforeStop, globalOption.shutdownTimeout = enableMetrics, beforeStop, timeout
+		globalOption.setGlobal = setGlobal
+		healthx.Default().Resume()
+	})
+	globalOption.enableMetrics, globalOption.setGlobal = false, false
+	globalOption.shutdownTimeout = 5 * time.Second
+
+	srv := grpc.NewServer(grpc.Address("127.0.0.1:0"), grpc.CustomHealth())
+	endpoint, err := srv.Endpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ggrpc.NewClient(endpoint.Host, ggrpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	check := func(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Errorf("health check: %v", err)
+			return grpc_health_v1.HealthCheckResponse_UNKNOWN
+		}
+		return resp.GetStatus()
+	}
+
+	// 开始关闭后、服务停止前检查 gRPC 健康状态
+	stopping := make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 1)
+	globalOption.beforeStop = []Hook{func(ctx context.Context) error {
+		stopping <- check(ctx)
+		return nil
+	}}
+
+	app := NewApp(log.NewStdLogger(io.Discard), srv)
+	done := make(chan error, 1)
+	go func() { done <- app.Run() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for check(ctx) != grpc_health_v1.HealthCheckResponse_SERVING {
+		if ctx.Err() != nil {
+			t.Fatal("grpc health never became SERVING")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := app.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if status := <-stopping; status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING after shutdown began, got %s", status)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewAppDefaultGrpcHealth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	srv := grpc.NewServer()
+	registerHealth(log.NewStdLogger(buf), []transport.Server{srv})
+
+	if !strings.Contains(buf.String(), "grpc.CustomHealth()") {
+		t.Fatalf("expected warning, got %q", buf.String())
+	}
+	if _, ok := srv.GetServiceInfo()[grpc_health_v1.Health_ServiceDesc.ServiceName]; !ok {
+		t.Fatal("expected kratos health service to be kept")
+	}
+}
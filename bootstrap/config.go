@@ -1,30 +1,272 @@
 package bootstrap
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
 	"github.com/go-kratos/kratos/v2/config"
-	"github.com/go-kratos/kratos/v2/config/env"
-	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
-func loadConfig(bc any) error {
+// configState 运行期的配置状态，在应用生命周期内保持打开以支持热更新
+type configState struct {
+	mu       sync.Mutex
+	c        config.Config
+	logger   *log.Helper
+	newFn    func() any             // 创建一个新的配置实例，用于重新加载
+	snapshot atomic.Pointer[any]    // 当前生效的配置快照，类型与 Run 传入的 T 一致
+	watchers map[string][]func(any) // 按 key 注册的回调
+	last     map[string]any         // 每个 key 最近一次通知的值，用于去重
+}
+
+var confState = &configState{
+	watchers: make(map[string][]func(any)),
+	last:     make(map[string]any),
+}
+
+// loadConfig 加载配置到 bc 并开始监听变化，返回清理函数
+func loadConfig(bc any, logger log.Logger) (func(), error) {
+	profile := currentProfile()
+	layers, err := configLayers(FlagConf, profile)
+	if err != nil {
+		return nil, err
+	}
+	return confState.load(bc, logger, profile, layers)
+}
+
+// load 从 layers 加载配置到 bc，保存为当前快照并开始监听变化
+func (s *configState) load(bc any, logger log.Logger, profile string, layers []configLayer) (func(), error) {
+	sources := make([]config.Source, 0, len(layers))
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		sources = append(sources, &reloadSource{Source: layer.source, reload: s.reload})
+		names = append(names, layer.name)
+	}
+
 	c := config.New(
-		config.WithSource(
-			env.NewSource(),
-			file.NewSource(FlagConf),
-		),
+		config.WithSource(sources...),
+		config.WithResolver(resolveConfig),
 	)
 
-	defer func() {
-		_ = c.Close()
-	}()
-
 	if err := c.Load(); err != nil {
-		return err
+		_ = c.Close()
+		return nil, err
 	}
 
 	if err := c.Scan(bc); err != nil {
-		return err
+		_ = c.Close()
+		return nil, err
+	}
+
+	// 在注入前校验配置，避免问题延迟到使用时才暴露
+	if err := validateConfig(bc); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.c = c
+	s.logger = log.NewHelper(logger)
+
+	// 优先级从低到高，后者覆盖前者
+	s.logger.Infow(
+		"msg", "config loaded",
+		"profile", profile,
+		"precedence", strings.Join(names, " < "),
+	)
+
+	s.newFn = newInstanceFunc(bc)
+	s.snapshot.Store(&bc)
+	root, _ := s.rootLocked()
+	for key := range s.watchers {
+		s.last[key], _ = lookup(root, key)
+	}
+	s.mu.Unlock()
+
+	cleanup := func() {
+		s.mu.Lock()
+		s.c = nil
+		s.mu.Unlock()
+		_ = c.Close()
+	}
+
+	return cleanup, nil
+}
+
+// reload 配置变化时重新加载完整配置，校验通过后替换快照并通知回调
+func (s *configState) reload() {
+	s.mu.Lock()
+
+	if s.c == nil || s.newFn == nil {
+		s.mu.Unlock()
+		return
+	}
+
+	next := s.newFn()
+	err := s.c.Scan(next)
+	if err == nil {
+		err = validateConfig(next)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		s.logger.Errorw("msg", "config reload rejected", "error", err)
+		return
+	}
+	s.snapshot.Store(&next)
+
+	// 收集需要通知的回调，释放锁后再执行，允许回调中再次调用 Watch
+	var notify []func()
+	if root, err := s.rootLocked(); err == nil {
+		for k, fns := range s.watchers {
+			v, _ := lookup(root, k)
+			if reflect.DeepEqual(v, s.last[k]) {
+				continue
+			}
+			s.last[k] = v
+			for _, fn := range fns {
+				notify = append(notify, func() { fn(v) })
+			}
+		}
 	}
+	s.mu.Unlock()
 
-	return nil
+	s.logger.Infow("msg", "config reloaded")
+	for _, fn := range notify {
+		fn()
+	}
+}
+
+// reloadSource 包装配置来源，每批变化合并完成后重新加载完整配置
+//
+// kratos 的 config.Watch 只能监听启动时已存在的 key，新增的顶层 key 不会触发回调，
+// 因此改为在 watcher 下一次调用 Next 时重新加载，此时上一批变化已经合并。
+type reloadSource struct {
+	config.Source
+	reload func()
+}
+
+func (s *reloadSource) Watch() (config.Watcher, error) {
+	w, err := s.Source.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return &reloadWatcher{Watcher: w, reload: s.reload}, nil
+}
+
+// reloadWatcher 在返回一批变化后的下一次 Next 中触发重新加载，只在 kratos 的监听协程中调用
+type reloadWatcher struct {
+	config.Watcher
+	reload  func()
+	pending bool
+}
+
+func (w *reloadWatcher) Next() ([]*config.KeyValue, error) {
+	if w.pending {
+		w.pending = false
+		w.reload()
+	}
+	kvs, err := w.Watcher.Next()
+	w.pending = err == nil
+	return kvs, err
+}
+
+// rootLocked 读取当前配置的原始结构
+func (s *configState) rootLocked() (map[string]any, error) {
+	root := make(map[string]any)
+	if s.c == nil {
+		return root, nil
+	}
+	if err := s.c.Scan(&root); err != nil {
+		return root, err
+	}
+	return root, nil
+}
+
+// Watch 监听配置项的变化，key 使用点分路径，如 "log.level"
+//
+// 只有整体配置重新加载并校验通过、且该 key 的值确实发生变化时才会回调，
+// 启动时不存在、之后新增的 key 同样会回调。回调前会把值解码为 V。可以在 Run 之前或在 runner 中注册。
+func Watch[V any](key string, fn func(V)) {
+	s := confState
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchers[key] = append(s.watchers[key], func(raw any) {
+		var v V
+		if err := decodeValue(raw, &v); err != nil {
+			s.logger.Errorw("msg", "decode config value failed", "key", key, "error", err)
+			return
+		}
+		fn(v)
+	})
+
+	if _, ok := s.last[key]; !ok && s.c != nil {
+		root, _ := s.rootLocked()
+		s.last[key], _ = lookup(root, key)
+	}
+}
+
+// Current 返回当前生效的配置快照
+//
+// T 必须与传给 Run 的配置类型一致，配置未加载或类型不一致时返回零值。
+func Current[T any]() T {
+	var v T
+	if p := confState.snapshot.Load(); p != nil {
+		v, _ = (*p).(T)
+	}
+	return v
+}
+
+// newInstanceFunc 返回创建与 v 同类型新实例的函数，v 需要是指针
+func newInstanceFunc(v any) func() any {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return nil
+	}
+	return func() any {
+		return reflect.New(t.Elem()).Interface()
+	}
+}
+
+// lookup 按点分路径读取原始配置中的值
+func lookup(root map[string]any, key string) (any, bool) {
+	var cur any = root
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// decodeValue 把原始配置值解码到 v
+func decodeValue(raw any, v any) error {
+	if raw == nil {
+		return errors.New("config value not found")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("marshal config value: %w", err)
+	}
+	// V 为 proto 消息指针时使用 protojson 解码
+	if rv := reflect.ValueOf(v).Elem(); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		if m, ok := rv.Interface().(proto.Message); ok {
+			return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+		}
+	}
+	return json.Unmarshal(data, v)
 }
@@ -0,0 +1,147 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// memSource 内存中的配置来源，通过 update 推送变化
+type memSource struct {
+	data  string
+	ch    chan string
+	calls atomic.Int32 // watcher 进入 Next 的次数
+}
+
+func (s *memSource) Load() ([]*config.KeyValue, error) {
+	return []*config.KeyValue{{Key: "config.yaml", Value: []byte(s.data), Format: "yaml"}}, nil
+}
+
+func (s *memSource) Watch() (config.Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &memWatcher{s: s, ctx: ctx, cancel: cancel}, nil
+}
+
+// update 推送新配置，等待其合并、重新加载完成
+func (s *memSource) update(t *testing.T, data string) {
+	t.Helper()
+	n := s.calls.Load()
+	s.ch <- data
+	deadline := time.Now().Add(5 * time.Second)
+	for s.calls.Load() <= n {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for config reload")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type memWatcher struct {
+	s      *memSource
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *memWatcher) Next() ([]*config.KeyValue, error) {
+	w.s.calls.Add(1)
+	select {
+	case data := <-w.s.ch:
+		return []*config.KeyValue{{Key: "config.yaml", Value: []byte(data), Format: "yaml"}}, nil
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	}
+}
+
+func (w *memWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+type watchConfig struct {
+	Server struct {
+		Addr string `json:"addr" validate:"required"`
+	} `json:"server"`
+	Log struct {
+		Level string `json:"level"`
+	} `json:"log"`
+}
+
+// loadTestConfig 使用内存来源加载配置，测试结束后清理全局状态
+func loadTestConfig(t *testing.T, data string) *memSource {
+	t.Helper()
+	src := &memSource{data: data, ch: make(chan string)}
+	cleanup, err := confState.load(&watchConfig{}, log.NewStdLogger(io.Discard), "", []configLayer{{name: "mem", source: src}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cleanup()
+		confState.mu.Lock()
+		clear(confState.watchers)
+		clear(confState.last)
+		confState.mu.Unlock()
+	})
+	for src.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return src
+}
+
+func TestConfigReload(t *testing.T) {
+	var (
+		levels   []string
+		features []map[string]bool
+	)
+	Watch("log.level", func(v string) { levels = append(levels, v) })
+	Watch("feature", func(v map[string]bool) { features = append(features, v) })
+
+	src := loadTestConfig(t, "server: {addr: ':8000'}\nlog: {level: info}")
+	if got := Current[*watchConfig]().Server.Addr; got != ":8000" {
+		t.Fatalf("Current() addr = %q", got)
+	}
+
+	// 只有变化的 key 触发回调
+	src.update(t, "server: {addr: ':9000'}\nlog: {level: info}")
+	if got := Current[*watchConfig]().Server.Addr; got != ":9000" || len(levels) != 0 {
+		t.Fatalf("addr = %q, level callbacks = %v", got, levels)
+	}
+
+	src.update(t, "server: {addr: ':9000'}\nlog: {level: debug}")
+	if len(levels) != 1 || levels[0] != "debug" {
+		t.Fatalf("level callbacks = %v", levels)
+	}
+
+	// 启动后新增的顶层 key 也能监听，并解码为 V
+	src.update(t, "server: {addr: ':9000'}\nlog: {level: debug}\nfeature: {beta: true}")
+	if len(features) != 1 || !features[0]["beta"] {
+		t.Fatalf("feature callbacks = %v", features)
+	}
+
+	// 校验失败时保留旧快照，不触发回调
+	src.update(t, "server: {addr: ''}\nlog: {level: warn}")
+	if got := Current[*watchConfig]().Server.Addr; got != ":9000" || len(levels) != 1 {
+		t.Fatalf("rejected reload applied: addr = %q, level callbacks = %v", got, levels)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	var d struct {
+		Timeout string `json:"timeout"`
+		Ports   []int  `json:"ports"`
+	}
+	if err := decodeValue(map[string]any{"timeout": "1s", "ports": []any{80, 443}}, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Timeout != "1s" || len(d.Ports) != 2 || d.Ports[1] != 443 {
+		t.Fatalf("unexpected value: %+v", d)
+	}
+	var n int
+	if err := decodeValue(nil, &n); err == nil {
+		t.Fatal("expected error for missing value")
+	}
+}
@@ -0,0 +1,373 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// 日志输出格式
+const (
+	FormatConsole = "console"
+	FormatJson    = "json"
+)
+
+// 日志中需要特殊渲染的字段
+const (
+	keyTs      = "ts"
+	keyCaller  = "caller"
+	keyTraceId = "trace_id"
+	keySpanId  = "span_id"
+)
+
+// Encoder 日志编码器，负责把一条日志编码后追加到 buf 中（需包含换行符）
+type Encoder interface {
+	Encode(buf *bytes.Buffer, level log.Level, keyvals []any)
+}
+
+// newEncoder 根据格式名称创建编码器，未知格式回退到 console
+func newEncoder(format string, color bool) Encoder {
+	if format == FormatJson {
+		return &JsonEncoder{}
+	}
+	return &ConsoleEncoder{Color: color}
+}
+
+/************************
+ * Logger
+ ************************/
+
+// encoderLogger 使用 Encoder 编码并写入 io.Writer 的日志器
+type encoderLogger struct {
+	w       io.Writer
+	encoder Encoder
+	mu      sync.Mutex
+	pool    *sync.Pool
+}
+
+// NewEncoderLogger 新建一个使用指定编码器的日志器
+func NewEncoderLogger(w io.Writer, encoder Encoder) log.Logger {
+	return &encoderLogger{
+		w:       w,
+		encoder: encoder,
+		pool: &sync.Pool{
+			New: func() any {
+				return new(bytes.Buffer)
+			},
+		},
+	}
+}
+
+func (l *encoderLogger) Log(level log.Level, keyvals ...any) error {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	if (len(keyvals) & 1) == 1 {
+		keyvals = append(keyvals, "KEYVALS UNPAIRED")
+	}
+
+	buf := l.pool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer l.pool.Put(buf)
+
+	l.encoder.Encode(buf, level, keyvals)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(buf.Bytes())
+	return err
+}
+
+/************************
+ * Json
+ ************************/
+
+// JsonEncoder 每条日志输出为一行 JSON，level 固定在首位，其余字段保持原有顺序
+type JsonEncoder struct{}
+
+func (e *JsonEncoder) Encode(buf *bytes.Buffer, level log.Level, keyvals []any) {
+	buf.WriteString(`{"level":`)
+	writeJsonString(buf, level.String())
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := toString(keyvals[i])
+		if isEmptyTraceField(key, keyvals[i+1]) {
+			continue
+		}
+		buf.WriteByte(',')
+		writeJsonString(buf, key)
+		buf.WriteByte(':')
+		writeJsonValue(buf, keyvals[i+1])
+	}
+
+	buf.WriteString("}\n")
+}
+
+// writeJsonValue 写入 JSON 值，基础类型直接写入，其余类型尝试 json 序列化
+func writeJsonValue(buf *bytes.Buffer, v any) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		writeJsonString(buf, t)
+	case []byte:
+		writeJsonString(buf, string(t))
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case int:
+		buf.WriteString(strconv.FormatInt(int64(t), 10))
+	case int8:
+		buf.WriteString(strconv.FormatInt(int64(t), 10))
+	case int16:
+		buf.WriteString(strconv.FormatInt(int64(t), 10))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(t), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(t, 10))
+	case uint:
+		buf.WriteString(strconv.FormatUint(uint64(t), 10))
+	case uint8:
+		buf.WriteString(strconv.FormatUint(uint64(t), 10))
+	case uint16:
+		buf.WriteString(strconv.FormatUint(uint64(t), 10))
+	case uint32:
+		buf.WriteString(strconv.FormatUint(uint64(t), 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(t, 10))
+	case float32, float64:
+		b, err := json.Marshal(t)
+		if err != nil {
+			// NaN、Inf 等无法表示为 JSON 数字
+			writeJsonString(buf, fmt.Sprint(t))
+			return
+		}
+		buf.Write(b)
+	case time.Duration:
+		writeJsonString(buf, t.String())
+	case time.Time:
+		writeJsonString(buf, t.Format(time.RFC3339Nano))
+	case error:
+		writeJsonString(buf, t.Error())
+	case fmt.Stringer:
+		writeJsonString(buf, t.String())
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			writeJsonString(buf, fmt.Sprint(t))
+			return
+		}
+		buf.Write(b)
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJsonString 写入转义后的 JSON 字符串
+func writeJsonString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// 非法 UTF-8 字节替换为 �
+			buf.WriteString(s[start:i])
+			buf.WriteString(`�`)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+/************************
+ * Console
+ ************************/
+
+// ConsoleEncoder 面向人阅读的单行格式：
+//
+//	2006-01-02 15:04:05 INFO  caller [trace_id span_id] key=value ...
+//
+// Color 为 true 时等级会带上 ANSI 颜色
+type ConsoleEncoder struct {
+	Color bool
+}
+
+const (
+	colorReset  = "\x1b[0m"
+	colorGray   = "\x1b[90m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorPurple = "\x1b[35m"
+	colorCyan   = "\x1b[36m"
+)
+
+// levelColors 各日志等级对应的颜色
+var levelColors = map[log.Level]string{
+	log.LevelDebug: colorPurple,
+	log.LevelInfo:  colorGreen,
+	log.LevelWarn:  colorYellow,
+	log.LevelError: colorRed,
+	log.LevelFatal: colorRed,
+}
+
+func (e *ConsoleEncoder) Encode(buf *bytes.Buffer, level log.Level, keyvals []any) {
+	var ts, callerPath, traceId, spanId string
+	rest := make([]any, 0, len(keyvals))
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := toString(keyvals[i])
+		switch key {
+		case keyTs:
+			ts = toString(keyvals[i+1])
+		case keyCaller:
+			callerPath = toString(keyvals[i+1])
+		case keyTraceId:
+			traceId = toString(keyvals[i+1])
+		case keySpanId:
+			spanId = toString(keyvals[i+1])
+		default:
+			rest = append(rest, key, keyvals[i+1])
+		}
+	}
+
+	if ts != "" {
+		e.paint(buf, colorGray, ts)
+		buf.WriteByte(' ')
+	}
+
+	lv := level.String()
+	e.paint(buf, levelColors[level], lv)
+	// 等级名对齐到 5 个字符
+	for i := len(lv); i < 5; i++ {
+		buf.WriteByte(' ')
+	}
+
+	if callerPath != "" {
+		buf.WriteByte(' ')
+		e.paint(buf, colorCyan, callerPath)
+	}
+
+	if traceId != "" || spanId != "" {
+		buf.WriteString(" [")
+		ids := traceId
+		if spanId != "" {
+			if ids != "" {
+				ids += " "
+			}
+			ids += spanId
+		}
+		e.paint(buf, colorBlue, ids)
+		buf.WriteByte(']')
+	}
+
+	for i := 0; i < len(rest); i += 2 {
+		buf.WriteByte(' ')
+		e.paint(buf, colorGray, rest[i].(string)+"=")
+		writeConsoleValue(buf, rest[i+1])
+	}
+
+	buf.WriteByte('\n')
+}
+
+// paint 按需为文本着色
+func (e *ConsoleEncoder) paint(buf *bytes.Buffer, color, s string) {
+	if !e.Color || color == "" {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+// writeConsoleValue 写入 console 格式的值，包含空白、引号或控制字符时加引号转义
+func writeConsoleValue(buf *bytes.Buffer, v any) {
+	s := toString(v)
+	if needsQuote(s) {
+		buf.WriteString(strconv.Quote(s))
+		return
+	}
+	buf.WriteString(s)
+}
+
+// needsQuote 判断 console 值是否需要加引号
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '"' || r == '=' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+/************************
+ * Helper
+ ************************/
+
+// toString 把任意值转成字符串
+func toString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// isEmptyTraceField 没有链路信息时省略 trace_id、span_id
+func isEmptyTraceField(key string, v any) bool {
+	if key != keyTraceId && key != keySpanId {
+		return false
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
@@ -0,0 +1,70 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestJsonEncoder_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewEncoderLogger(&buf, &JsonEncoder{})
+
+	_ = logger.Log(log.LevelWarn,
+		"ts", "2026-01-02 15:04:05",
+		"caller", "main.go:10",
+		"trace_id", "",
+		"msg", "quote \" backslash \\ newline \n tab \t ctrl \x01",
+		"n", 42,
+		"ok", true,
+		"err", errors.New("boom"),
+	)
+
+	line := buf.String()
+	if !strings.HasSuffix(line, "}\n") || strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected a single json line, got %q", line)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q: %v", line, err)
+	}
+	if m["level"] != "WARN" {
+		t.Errorf("unexpected level: %v", m["level"])
+	}
+	if m["msg"] != "quote \" backslash \\ newline \n tab \t ctrl \x01" {
+		t.Errorf("unexpected msg: %q", m["msg"])
+	}
+	if m["n"] != float64(42) || m["ok"] != true || m["err"] != "boom" {
+		t.Errorf("unexpected values: %v", m)
+	}
+	if _, ok := m["trace_id"]; ok {
+		t.Errorf("empty trace_id should be omitted")
+	}
+	if !strings.HasPrefix(line, `{"level":"WARN","ts":`) {
+		t.Errorf("fields should keep their order, got %q", line)
+	}
+}
+
+func TestConsoleEncoder_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewEncoderLogger(&buf, &ConsoleEncoder{})
+
+	_ = logger.Log(log.LevelInfo,
+		"ts", "15:04:05",
+		"caller", "main.go:10",
+		"trace_id", "abc",
+		"span_id", "",
+		"msg", "hello world",
+		"k", "v",
+	)
+
+	want := `15:04:05 INFO  main.go:10 [abc] msg="hello world" k=v` + "\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
@@ -3,28 +3,176 @@ package bootstrap
 import (
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/joho/godotenv"
+	"github.com/lhlyu/kratos-easy/constants"
 )
 
-// env 文件加载优先级（只加载第一个存在的）
-var envCandidates = []string{
-	".env",
-	"local.env",
-	"dev.env",
-	"develop.env",
-	"development.env",
-	"staging.env",
-	"prod.env",
-	"production.env",
+// 基础和本地覆盖的 env 文件
+const (
+	baseEnvFile  = ".env"
+	localEnvFile = ".env.local"
+)
+
+// envAliases 各环境对应的 env 文件，按顺序取第一个存在的
+var envAliases = map[string][]string{
+	constants.EnvProduction:  {"production.env", "prod.env"},
+	constants.EnvStaging:     {"staging.env"},
+	constants.EnvDevelopment: {"development.env", "develop.env", "dev.env"},
+	constants.EnvLocal:       {"local.env"},
+}
+
+// envSensitiveWords 变量名包含这些词时，调试输出会脱敏
+var envSensitiveWords = []string{"PASS", "SECRET", "TOKEN", "KEY", "DSN", "CREDENTIAL", "PRIVATE"}
+
+// 值中的凭证，调试输出时替换为 ******
+var (
+	// urlUserinfo 匹配 URL 中的 userinfo，如 redis://:pw@host、amqp://user:pw@host
+	urlUserinfo = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.\-]*://)[^/?#\s]+@`)
+	// urlPassword 匹配 URL 查询参数中的密码，如 ?password=pw
+	urlPassword = regexp.MustCompile(`(?i)([?&](?:password|passwd|pwd|secret|token)=)[^&#\s]*`)
+	// dsnPassword 匹配 go-sql-driver 格式 DSN 中的密码，如 user:pw@tcp(host:3306)/db、user:pw@/db
+	dsnPassword = regexp.MustCompile(`^([^\s:/@]*):\S*@(\w*\(|/)`)
+)
+
+// envVar 从 env 文件解析出的变量
+type envVar struct {
+	key    string
+	value  string // 最终生效的值
+	source string // 来源文件，被进程环境变量覆盖时为 "process"
+}
+
+// envResult 记录 env 文件的加载结果，待日志初始化后输出
+var envResult struct {
+	files []string
+	vars  []envVar
+	errs  []error
 }
 
+// initEnv 按层加载 env 文件，优先级从低到高：
+//
+//	.env < <APP_ENV>.env < .env.local < 进程环境变量
+//
+// 已存在于进程环境中的变量永远不会被覆盖。APP_ENV 未设置时会从 .env 中读取。
 func initEnv(root string) {
-	for _, name := range envCandidates {
+	base, err := readEnvFile(filepath.Join(root, baseEnvFile))
+	if err != nil {
+		envResult.errs = append(envResult.errs, err)
+	}
+
+	profile := os.Getenv(constants.AppEnv)
+	if profile == "" {
+		profile = base[constants.AppEnv]
+	}
+
+	layers := []string{filepath.Join(root, baseEnvFile)}
+	if name := profileEnvFile(root, profile); name != "" {
+		layers = append(layers, name)
+	}
+	layers = append(layers, filepath.Join(root, localEnvFile))
+
+	merged := make(map[string]string)
+	sources := make(map[string]string)
+	for _, path := range layers {
+		vars, err := readEnvFile(path)
+		if err != nil {
+			envResult.errs = append(envResult.errs, err)
+			continue
+		}
+		if vars == nil {
+			continue
+		}
+		envResult.files = append(envResult.files, path)
+		for k, v := range vars {
+			merged[k] = v
+			sources[k] = path
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok {
+			envResult.vars = append(envResult.vars, envVar{key: k, value: v, source: "process"})
+			continue
+		}
+		_ = os.Setenv(k, merged[k])
+		envResult.vars = append(envResult.vars, envVar{key: k, value: merged[k], source: sources[k]})
+	}
+}
+
+// profileEnvFile 返回当前环境对应的 env 文件，不存在时返回空字符串
+func profileEnvFile(root, profile string) string {
+	if profile == "" {
+		return ""
+	}
+	names, ok := envAliases[profile]
+	if !ok {
+		names = []string{profile + ".env"}
+	}
+	for _, name := range names {
 		path := filepath.Join(root, name)
-		if _, err := os.Stat(path); err == nil {
-			_ = godotenv.Load(path)
-			return
+		if isFile(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// readEnvFile 读取 env 文件，文件不存在时返回 nil
+func readEnvFile(path string) (map[string]string, error) {
+	if !isFile(path) {
+		return nil, nil
+	}
+	return godotenv.Read(path)
+}
+
+// logEnv 输出 env 文件的加载结果
+func logEnv(logger log.Logger) {
+	l := log.NewHelper(logger)
+	for _, err := range envResult.errs {
+		l.Warnw("msg", "load env file failed", "error", err)
+	}
+	if len(envResult.files) == 0 {
+		return
+	}
+	l.Infow("msg", "env files applied", "files", strings.Join(envResult.files, ", "))
+}
+
+// dumpEnv 调试等级下输出每个变量脱敏后的值和来源
+//
+// 在配置加载之后调用，此时配置中解析出的密钥已经登记，可以一并脱敏。
+func dumpEnv(logger log.Logger) {
+	l := log.NewHelper(logger)
+	for _, v := range envResult.vars {
+		l.Debugw("msg", "env", "key", v.key, "value", maskEnvValue(v.key, v.value), "source", v.source)
+	}
+}
+
+// maskEnvValue 对敏感变量的值脱敏，其余变量去掉 URL、DSN 中的凭证
+func maskEnvValue(key, value string) string {
+	upper := strings.ToUpper(key)
+	for _, w := range envSensitiveWords {
+		if strings.Contains(upper, w) {
+			return secretMask
 		}
 	}
+	return MaskSecrets(maskCredentials(value))
+}
+
+// maskCredentials 把 URL 的 userinfo、密码参数以及 DSN 中的密码替换为 ******
+func maskCredentials(value string) string {
+	if strings.Contains(value, "://") {
+		value = urlUserinfo.ReplaceAllString(value, "${1}"+secretMask+"@")
+		return urlPassword.ReplaceAllString(value, "${1}"+secretMask)
+	}
+	return dsnPassword.ReplaceAllString(value, "${1}:"+secretMask+"@${2}")
 }
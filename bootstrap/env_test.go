@@ -0,0 +1,96 @@
+package bootstrap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/constants"
+)
+
+func TestMaskEnvValue(t *testing.T) {
+	secrets.add("registered-secret")
+
+	cases := []struct {
+		key, value, want string
+	}{
+		{"DB_PASSWORD", "root", "******"},
+		{"JWT_SECRET", "abc", "******"},
+		{"MYSQL_DSN", "root:pw@tcp(127.0.0.1:3306)/db", "******"},
+		{"REDIS_URL", "redis://:pw@127.0.0.1:6379/0", "redis://******@127.0.0.1:6379/0"},
+		{"AMQP_URL", "amqp://user:p@ss@mq:5672/", "amqp://******@mq:5672/"},
+		{"SENTINEL_URL", "redis://10.0.0.1:26379,10.0.0.2:26379/0?password=pw&db=1", "redis://10.0.0.1:26379,10.0.0.2:26379/0?password=******&db=1"},
+		{"MYSQL_URL", "user:pw@tcp(db:3306)/app?parseTime=true", "user:******@tcp(db:3306)/app?parseTime=true"},
+		{"MYSQL_LOCAL", "root:pw@/app", "root:******@/app"},
+		{"MYSQL_URL", "root@tcp(db:3306)/app", "root@tcp(db:3306)/app"},
+		{"HTTP_ADDR", "0.0.0.0:8000", "0.0.0.0:8000"},
+		{"HOME_PAGE", "https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"UPSTREAM", "value registered-secret", "value ******"},
+	}
+	for _, c := range cases {
+		if got := maskEnvValue(c.key, c.value); got != c.want {
+			t.Errorf("%s=%s: got %q, want %q", c.key, c.value, got, c.want)
+		}
+	}
+}
+
+func TestInitEnv(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".env":            "APP_ENV=production\nTEST_ENV_A=base\nTEST_ENV_B=base\nTEST_ENV_C=base\nTEST_ENV_URL=redis://:pw@cache:6379/0\n",
+		"prod.env":        "TEST_ENV_B=prod\nTEST_ENV_C=prod\n",
+		"development.env": "TEST_ENV_C=dev\n",
+		".env.local":      "TEST_ENV_C=local\nTEST_ENV_D=local\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 测试结束后恢复为未设置
+	for _, k := range []string{constants.AppEnv, "TEST_ENV_A", "TEST_ENV_B", "TEST_ENV_C", "TEST_ENV_URL"} {
+		t.Setenv(k, "")
+		_ = os.Unsetenv(k)
+	}
+	t.Setenv("TEST_ENV_D", "process")
+
+	saved := envResult
+	t.Cleanup(func() { envResult = saved })
+	envResult.files, envResult.vars, envResult.errs = nil, nil, nil
+
+	initEnv(root)
+
+	want := map[string]string{
+		constants.AppEnv: "production",
+		"TEST_ENV_A":     "base",
+		"TEST_ENV_B":     "prod",
+		"TEST_ENV_C":     "local",
+		"TEST_ENV_D":     "process",
+	}
+	for k, v := range want {
+		if got := os.Getenv(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if len(envResult.files) != 3 || filepath.Base(envResult.files[1]) != "prod.env" {
+		t.Fatalf("unexpected files: %v", envResult.files)
+	}
+
+	buf := &bytes.Buffer{}
+	logger := log.NewFilter(log.NewStdLogger(buf), log.FilterLevel(log.LevelDebug))
+	logEnv(logger)
+	dumpEnv(logger)
+	out := buf.String()
+	for _, s := range []string{"env files applied", "key=TEST_ENV_D value=process source=process", "value=redis://******@cache:6379/0"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected %q in output:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, ":pw@") {
+		t.Errorf("credentials leaked:\n%s", out)
+	}
+}
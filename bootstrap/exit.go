@@ -0,0 +1,65 @@
+package bootstrap
+
+import "errors"
+
+// 进程退出码，按失败阶段区分
+const (
+	ExitOK      = 0 // 正常退出
+	ExitUnknown = 1 // 未分类的错误
+	ExitConfig  = 2 // 配置加载、校验或初始化失败
+	ExitWire    = 3 // 依赖注入（wire）失败
+	ExitRuntime = 4 // 服务运行期间失败
+	ExitTracing = 5 // 链路追踪初始化失败
+)
+
+// 失败阶段
+const (
+	StageConfig  = "config"
+	StageWire    = "wire"
+	StageRuntime = "runtime"
+	StageTracing = "tracing"
+)
+
+// RunError 启动流程中的错误，记录失败阶段和对应的退出码
+type RunError struct {
+	Stage string
+	Code  int
+	Err   error
+}
+
+func (e *RunError) Error() string {
+	return e.Stage + ": " + e.Err.Error()
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// stageCodes 失败阶段与退出码的对应关系
+var stageCodes = map[string]int{
+	StageConfig:  ExitConfig,
+	StageWire:    ExitWire,
+	StageRuntime: ExitRuntime,
+	StageTracing: ExitTracing,
+}
+
+// newRunError 包装指定阶段的错误
+func newRunError(stage string, err error) error {
+	code, ok := stageCodes[stage]
+	if !ok {
+		code = ExitUnknown
+	}
+	return &RunError{Stage: stage, Code: code, Err: err}
+}
+
+// ExitCode 返回错误对应的进程退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var re *RunError
+	if errors.As(err, &re) {
+		return re.Code
+	}
+	return ExitUnknown
+}
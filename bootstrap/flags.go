@@ -11,25 +11,11 @@ var FlagConf string
 // ConfDir 配置文件所在的目录
 var ConfDir string
 
-// 默认配置文件优先级
-var defaultConfCandidates = []string{
-	"config.test.yaml",
-	"config.local.yaml",
-	"config.yaml",
-}
-
-// findDefaultConf 返回第一个存在的配置文件，如果都不存在就返回 configs 目录
+// findDefaultConf 返回默认的配置目录
+//
+// 目录下的 config.yaml 为基础配置，config.<APP_ENV>.yaml 为 profile 覆盖配置。
 func findDefaultConf(root string) string {
-	dir := globalOption.configDir
-
-	for _, name := range defaultConfCandidates {
-		path := filepath.Join(root, dir, name)
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
-	// 都没找到就用默认路径
-	return filepath.Join(root, dir)
+	return filepath.Join(root, globalOption.configDir)
 }
 
 // updateConfDir 更新 ConfDir
@@ -19,6 +19,7 @@ func Init(opts ...Option) {
 				opts,
 				WithTimeLayout(time.TimeOnly),
 				WithFilterLevel(log.LevelDebug),
+				WithColor(),
 			)
 		}
 		if constants.IsDevelopment() {
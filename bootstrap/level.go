@@ -0,0 +1,200 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// levelState 运行期可调整的日志等级
+type levelState struct {
+	level atomic.Int32
+
+	mu    sync.Mutex
+	base  log.Level   // 临时等级到期后恢复的等级
+	timer *time.Timer // 临时等级的恢复定时器
+}
+
+var logLevel = &levelState{}
+
+// get 返回当前日志等级
+func (s *levelState) get() log.Level {
+	return log.Level(s.level.Load())
+}
+
+// set 永久设置日志等级，会取消尚未到期的临时等级
+func (s *levelState) set(level log.Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTimerLocked()
+	s.base = level
+	s.level.Store(int32(level))
+}
+
+// setFor 临时设置日志等级，ttl 到期后恢复为原来的等级
+func (s *levelState) setFor(level log.Level, ttl time.Duration) {
+	if ttl <= 0 {
+		s.set(level)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopTimerLocked()
+	s.level.Store(int32(level))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// 期间等级被再次修改时不再恢复
+		if s.timer != timer {
+			return
+		}
+		s.timer = nil
+		s.level.Store(int32(s.base))
+	})
+	s.timer = timer
+}
+
+func (s *levelState) stopTimerLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// GetLogLevel 返回当前生效的日志过滤等级
+func GetLogLevel() log.Level {
+	return logLevel.get()
+}
+
+// SetLogLevel 修改日志过滤等级，立即生效
+func SetLogLevel(level log.Level) {
+	logLevel.set(level)
+}
+
+// SetLogLevelFor 临时修改日志过滤等级，ttl 到期后恢复为原来的等级
+func SetLogLevelFor(level log.Level, ttl time.Duration) {
+	logLevel.setFor(level, ttl)
+}
+
+// levels 按从低到高排列的日志等级
+var levels = []log.Level{
+	log.LevelDebug,
+	log.LevelInfo,
+	log.LevelWarn,
+	log.LevelError,
+	log.LevelFatal,
+}
+
+// shiftLogLevel 把日志等级上调（step > 0）或下调（step < 0），超出范围时保持边界值
+func shiftLogLevel(step int) log.Level {
+	cur := GetLogLevel()
+	idx := 0
+	for i, l := range levels {
+		if l == cur {
+			idx = i
+			break
+		}
+	}
+	next := levels[min(max(idx+step, 0), len(levels)-1)]
+	SetLogLevel(next)
+	return next
+}
+
+// parseLevel 解析日志等级，不区分大小写
+func parseLevel(s string) (log.Level, bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return log.LevelDebug, true
+	case "INFO":
+		return log.LevelInfo, true
+	case "WARN":
+		return log.LevelWarn, true
+	case "ERROR":
+		return log.LevelError, true
+	case "FATAL":
+		return log.LevelFatal, true
+	}
+	return 0, false
+}
+
+/************************
+ * Filter
+ ************************/
+
+// levelLogger 按当前动态等级过滤日志
+type levelLogger struct {
+	logger log.Logger
+	level  *levelState
+}
+
+func (l *levelLogger) Log(level log.Level, keyvals ...any) error {
+	if level < l.level.get() {
+		return nil
+	}
+	return l.logger.Log(level, keyvals...)
+}
+
+/************************
+ * Admin Handler
+ ************************/
+
+// levelResponse 日志等级接口的返回值
+type levelResponse struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler 返回查看和修改日志等级的 HTTP 处理器，供服务自行挂载到管理路由
+//
+//	GET  查看当前等级
+//	PUT  修改等级，参数 level=debug|info|warn|error|fatal，可选 ttl=10m 表示临时修改
+//
+// 参数可以放在 query 中，也可以是 JSON body：{"level":"debug","ttl":"10m"}
+func LogLevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := struct {
+				Level string `json:"level"`
+				Ttl   string `json:"ttl"`
+			}{
+				Level: r.URL.Query().Get("level"),
+				Ttl:   r.URL.Query().Get("ttl"),
+			}
+			if req.Level == "" && r.Body != nil {
+				_ = json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req)
+			}
+
+			level, ok := parseLevel(req.Level)
+			if !ok {
+				http.Error(w, "invalid level: "+req.Level, http.StatusBadRequest)
+				return
+			}
+
+			var ttl time.Duration
+			if req.Ttl != "" {
+				d, err := time.ParseDuration(req.Ttl)
+				if err != nil || d < 0 {
+					http.Error(w, "invalid ttl: "+req.Ttl, http.StatusBadRequest)
+					return
+				}
+				ttl = d
+			}
+			SetLogLevelFor(level, ttl)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(levelResponse{Level: GetLogLevel().String()})
+	})
+}
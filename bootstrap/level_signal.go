@@ -0,0 +1,42 @@
+//go:build !windows
+
+package bootstrap
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// watchLevelSignals 监听信号调整日志等级，返回停止函数
+//
+//   - SIGUSR1 上调一级（如 INFO -> WARN），减少输出
+//   - SIGUSR2 下调一级（如 INFO -> DEBUG），输出更详细
+func watchLevelSignals(logger log.Logger) func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-c:
+				step := 1
+				if sig == syscall.SIGUSR2 {
+					step = -1
+				}
+				level := shiftLogLevel(step)
+				_ = logger.Log(log.LevelWarn, "msg", "log level changed by signal", "signal", sig.String(), "level", level.String())
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
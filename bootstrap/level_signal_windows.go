@@ -0,0 +1,10 @@
+//go:build windows
+
+package bootstrap
+
+import "github.com/go-kratos/kratos/v2/log"
+
+// watchLevelSignals Windows 不支持 SIGUSR1/SIGUSR2，不做任何处理
+func watchLevelSignals(log.Logger) func() {
+	return func() {}
+}
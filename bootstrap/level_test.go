@@ -0,0 +1,84 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	SetLogLevel(log.LevelInfo)
+	t.Cleanup(func() { SetLogLevel(log.LevelInfo) })
+	h := LogLevelHandler()
+
+	do := func(method, target, body string) (int, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var resp levelResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Level
+	}
+
+	if code, level := do(http.MethodGet, "/", ""); code != http.StatusOK || level != "INFO" {
+		t.Fatalf("GET = %d %s", code, level)
+	}
+	if code, level := do(http.MethodPut, "/?level=debug", ""); code != http.StatusOK || level != "DEBUG" || GetLogLevel() != log.LevelDebug {
+		t.Fatalf("PUT query = %d %s", code, level)
+	}
+	if code, level := do(http.MethodPut, "/", `{"level":"WARN"}`); code != http.StatusOK || level != "WARN" {
+		t.Fatalf("PUT json = %d %s", code, level)
+	}
+
+	// 临时修改到期后恢复
+	if code, level := do(http.MethodPost, "/", `{"level":"error","ttl":"50ms"}`); code != http.StatusOK || level != "ERROR" {
+		t.Fatalf("PUT ttl = %d %s", code, level)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for GetLogLevel() != log.LevelWarn {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reverted, got %s", GetLogLevel())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 临时修改期间再次永久修改，到期后不再恢复
+	do(http.MethodPut, "/?level=debug&ttl=20ms", "")
+	do(http.MethodPut, "/?level=info", "")
+	time.Sleep(50 * time.Millisecond)
+	if GetLogLevel() != log.LevelInfo {
+		t.Fatalf("expected INFO to be kept, got %s", GetLogLevel())
+	}
+
+	for _, target := range []string{"/?level=verbose", "/?level=", "/?level=debug&ttl=soon", "/?level=debug&ttl=-1m"} {
+		if code, _ := do(http.MethodPut, target, ""); code != http.StatusBadRequest {
+			t.Fatalf("PUT %s = %d, want 400", target, code)
+		}
+	}
+	if GetLogLevel() != log.LevelInfo {
+		t.Fatalf("invalid requests changed level to %s", GetLogLevel())
+	}
+	if code, _ := do(http.MethodDelete, "/", ""); code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE = %d, want 405", code)
+	}
+}
+
+func TestShiftLogLevel(t *testing.T) {
+	SetLogLevel(log.LevelInfo)
+	t.Cleanup(func() { SetLogLevel(log.LevelInfo) })
+
+	if shiftLogLevel(1) != log.LevelWarn || shiftLogLevel(-2) != log.LevelDebug || shiftLogLevel(-1) != log.LevelDebug {
+		t.Fatal("unexpected shifted level")
+	}
+	for range 10 {
+		shiftLogLevel(1)
+	}
+	if GetLogLevel() != log.LevelFatal {
+		t.Fatalf("expected FATAL at the upper bound, got %s", GetLogLevel())
+	}
+}
@@ -2,148 +2,14 @@ package bootstrap
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
-	"sync"
-	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware/tracing"
 	"github.com/lhlyu/kratos-easy/constants"
 )
 
-// dailyRotateWriter 按日期分割的日志写入器
-type dailyRotateWriter struct {
-	dir     string
-	maxDays int
-	mu      sync.Mutex
-	file    *os.File
-	lastDay string
-	closed  bool
-}
-
-func (w *dailyRotateWriter) Write(p []byte) (n int, err error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	if w.closed {
-		return 0, nil // 闭合后静默丢弃，避免影响 shutdown 流程
-	}
-
-	day := time.Now().Format("2006-01-02")
-
-	// 1. 日期切换或文件未打开
-	if day != w.lastDay || w.file == nil {
-		isNewDay := day != w.lastDay
-		if err = w.rotate(day); err != nil {
-			return 0, err
-		}
-		if isNewDay {
-			go cleanOldLogs(w.dir, w.maxDays)
-		}
-	}
-
-	// 2. 尝试写入
-	n, err = w.file.Write(p)
-	if err != nil {
-		// 3. 写入失败尝试重试一次（可能是文件被删或其它 IO 异常）
-		_, _ = os.Stderr.WriteString("write log failed, trying to reopen: " + err.Error() + "\n")
-		if err = w.reopen(); err != nil {
-			return 0, err
-		}
-		return w.file.Write(p)
-	}
-
-	return n, nil
-}
-
-func (w *dailyRotateWriter) rotate(day string) error {
-	if w.file != nil {
-		_, _ = fmt.Fprintf(w.file, "\n%s [ROTATE] Log rotated from %s to %s\n", time.Now().Format("2006-01-02 15:04:05"), w.lastDay, day)
-		_ = w.file.Close()
-		w.file = nil
-	}
-
-	return w.open(day)
-}
-
-func (w *dailyRotateWriter) open(day string) error {
-	if err := os.MkdirAll(w.dir, 0755); err != nil {
-		return fmt.Errorf("failed to create log directory %s: %w", w.dir, err)
-	}
-	filename := filepath.Join(w.dir, day+".log")
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open log file %s: %w", filename, err)
-	}
-	w.file = f
-	w.lastDay = day
-	return nil
-}
-
-func (w *dailyRotateWriter) reopen() error {
-	day := time.Now().Format("2006-01-02")
-	if w.file != nil {
-		_ = w.file.Close()
-		w.file = nil
-	}
-	return w.open(day)
-}
-
-func (w *dailyRotateWriter) Sync() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	if w.file != nil {
-		return w.file.Sync()
-	}
-	return nil
-}
-
-// Close 关闭日志文件
-func (w *dailyRotateWriter) Close() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	if w.closed {
-		return nil
-	}
-	w.closed = true
-	if w.file != nil {
-		return w.file.Close()
-	}
-	return nil
-}
-
-// cleanOldLogs 清理过期日志
-func cleanOldLogs(dir string, maxDays int) {
-	if maxDays <= 0 {
-		return
-	}
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return
-	}
-
-	cutoff := time.Now().AddDate(0, 0, -maxDays)
-	for _, f := range files {
-		if f.IsDir() || !strings.HasSuffix(f.Name(), ".log") {
-			continue
-		}
-		// 期望格式 2006-01-02.log
-		name := strings.TrimSuffix(f.Name(), ".log")
-		t, err := time.Parse("2006-01-02", name)
-		if err != nil {
-			continue
-		}
-		if t.Before(cutoff) {
-			_ = os.Remove(filepath.Join(dir, f.Name()))
-		}
-	}
-}
-
 // caller 打印详细的调用路径
 func caller(depth int) log.Valuer {
 	return func(context.Context) any {
@@ -157,24 +23,34 @@ func newLogger() (log.Logger, func()) {
 	var fileWriter *dailyRotateWriter
 
 	// 基础日志器，默认输出到终端
-	baseLogger := log.NewStdLogger(globalOption.writer)
+	encoder := globalOption.encoder
+	if encoder == nil {
+		encoder = newEncoder(globalOption.format, globalOption.enableColor)
+	}
+	baseLogger := NewEncoderLogger(globalOption.writer, encoder)
 
 	// 非本地环境且开启了文件日志，则同时写入文件(JSON)
 	if !constants.IsLocal() && globalOption.enableFile {
 		// 程序运行前检查并删除过期日志
-		cleanOldLogs(globalOption.logDir, globalOption.logMaxDays)
+		cleanOldLogs(globalOption.logDir, globalOption.logMaxDays, globalOption.logMaxTotalSize, "")
 
 		fileWriter = &dailyRotateWriter{
-			dir:     globalOption.logDir,
-			maxDays: globalOption.logMaxDays,
+			dir:          globalOption.logDir,
+			maxDays:      globalOption.logMaxDays,
+			maxSize:      globalOption.logMaxSize,
+			maxTotalSize: globalOption.logMaxTotalSize,
+			compress:     globalOption.logCompress,
 		}
 
-		kzLogger := log.NewStdLogger(fileWriter)
+		fileLogger := NewEncoderLogger(fileWriter, &JsonEncoder{})
 
-		baseLogger = MultiLogger(baseLogger, kzLogger)
+		baseLogger = MultiLogger(baseLogger, fileLogger)
 	}
 
-	filteredLogger := log.NewFilter(baseLogger, log.FilterLevel(globalOption.level))
+	// 过滤等级可在运行期通过 SetLogLevel、LogLevelHandler 或信号调整
+	logLevel.set(globalOption.level)
+	// 写入前统一脱敏，自定义编码器和文件日志同样生效
+	filteredLogger := &levelLogger{logger: MaskLogger(baseLogger), level: logLevel}
 
 	callerValuer := log.DefaultCaller
 	if globalOption.enableFullCaller {
@@ -182,19 +58,19 @@ func newLogger() (log.Logger, func()) {
 	}
 
 	kvs := []any{
-		"ts", log.Timestamp(globalOption.timeLayout),
-		"caller", callerValuer,
+		keyTs, log.Timestamp(globalOption.timeLayout),
+		keyCaller, callerValuer,
 	}
 
 	if globalOption.enableTrace {
 		kvs = append(kvs,
-			"trace_id", tracing.TraceID(),
+			keyTraceId, tracing.TraceID(),
 		)
 	}
 
 	if globalOption.enableSpan {
 		kvs = append(kvs,
-			"span_id", tracing.SpanID(),
+			keySpanId, tracing.SpanID(),
 		)
 	}
 
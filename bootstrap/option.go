@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
@@ -21,14 +22,37 @@ type options struct {
 	configDir        string    // 配置文件目录路径，默认 "configs"
 	logDir           string    // 日志目录，默认 "logs"
 	logMaxDays       int       // 日志最大保留天数，默认 7 天
+	logMaxSize       int64     // 单个日志文件最大字节数，超过后切分为 2006-01-02.1.log，默认 0 不切分
+	logMaxTotalSize  int64     // 日志目录总字节数上限，超过后从最旧的文件开始删除，默认 0 不限制
+	logCompress      bool      // 是否 gzip 压缩已关闭的日志文件，默认 false
 	enableFile       bool      // 是否写入文件，默认 true
 	enableFullCaller bool      // 是否启用打印详细的调用路径，默认 false
+	enableColor      bool      // console 格式是否输出颜色，本地环境默认开启
+	encoder          Encoder   // 自定义终端日志编码器，设置后忽略 format
+
+	traceProtocol     string        // OTLP 导出协议，可选 "grpc" 或 "http"，默认 "grpc"
+	traceEndpoint     string        // OTLP 导出地址，如 "localhost:4317"，为空时不导出
+	traceInsecure     bool          // 是否使用非 TLS 连接导出，默认 false
+	traceSampleRatio  float64       // 采样率，取值 0 ~ 1，默认 1 全量采样
+	traceBatchSize    int           // 单批次最大导出 span 数，默认 0 使用 SDK 默认值
+	traceBatchTimeout time.Duration // 批次最长等待时间，默认 0 使用 SDK 默认值
+
+	beforeStart     []Hook        // 服务启动前执行的钩子，按注册顺序执行
+	afterStart      []Hook        // 服务启动后执行的钩子，按注册顺序执行
+	beforeStop      []Hook        // 服务停止前执行的钩子，按注册顺序执行
+	afterStop       []Hook        // 服务停止后执行的钩子，按注册顺序执行
+	shutdownTimeout time.Duration // 优雅关闭的最长等待时间，默认 30 秒，<=0 表示不限制
+
+	secretResolvers []SecretResolver // 自定义的密钥解析器，优先于内置的 file://、env://、enc:
+
+	enableHealth  bool // 是否在 NewApp 中注册健康检查，默认 true
+	enableMetrics bool // 是否在 NewApp 中挂载 /metrics，默认 false
 }
 
 var globalOption = &options{
 	writer:           os.Stdout,
 	level:            log.LevelInfo,
-	format:           "console",
+	format:           FormatConsole,
 	timeLayout:       time.DateTime,
 	enableTrace:      true,
 	enableSpan:       false,
@@ -36,12 +60,26 @@ var globalOption = &options{
 	configDir:        "configs",
 	logDir:           "logs",
 	logMaxDays:       7,
+	logMaxSize:       0,
+	logMaxTotalSize:  0,
+	logCompress:      false,
 	enableFile:       true,
 	enableFullCaller: false,
+	enableColor:      false,
+
+	traceProtocol:    ProtocolGrpc,
+	traceSampleRatio: 1,
+
+	shutdownTimeout: 30 * time.Second,
+
+	enableHealth: true,
 }
 
 type Option func(*options)
 
+// Hook 应用生命周期钩子，由 NewApp 注册到 kratos.App
+type Hook func(ctx context.Context) error
+
 // WithWriter 修改日志写入器
 func WithWriter(writer io.Writer) Option {
 	return func(o *options) {
@@ -59,14 +97,14 @@ func WithFilterLevel(level log.Level) Option {
 // WithJsonFormat 日志输出格式改成 json
 func WithJsonFormat() Option {
 	return func(o *options) {
-		o.format = "json"
+		o.format = FormatJson
 	}
 }
 
 // WithConsoleFormat 日志输出格式改成 console
 func WithConsoleFormat() Option {
 	return func(o *options) {
-		o.format = "console"
+		o.format = FormatConsole
 	}
 }
 
@@ -119,6 +157,27 @@ func WithLogMaxDays(days int) Option {
 	}
 }
 
+// WithLogMaxSize 设置单个日志文件最大大小（MB），超过后切分出新的分段文件
+func WithLogMaxSize(mb int) Option {
+	return func(o *options) {
+		o.logMaxSize = int64(mb) << 20
+	}
+}
+
+// WithLogMaxTotalSize 设置日志目录总大小上限（MB），超过后从最旧的文件开始删除
+func WithLogMaxTotalSize(mb int) Option {
+	return func(o *options) {
+		o.logMaxTotalSize = int64(mb) << 20
+	}
+}
+
+// WithLogCompress 启用 gzip 压缩已关闭的日志文件
+func WithLogCompress() Option {
+	return func(o *options) {
+		o.logCompress = true
+	}
+}
+
 // WithDisableFileLog 禁用文件日志输出
 func WithDisableFileLog() Option {
 	return func(o *options) {
@@ -132,3 +191,116 @@ func WithFullCaller() Option {
 		o.enableFullCaller = true
 	}
 }
+
+// WithColor 启用 console 格式的彩色输出
+func WithColor() Option {
+	return func(o *options) {
+		o.enableColor = true
+	}
+}
+
+// WithEncoder 设置自定义的终端日志编码器
+func WithEncoder(encoder Encoder) Option {
+	return func(o *options) {
+		o.encoder = encoder
+	}
+}
+
+// WithOtlpGrpc 通过 OTLP gRPC 导出链路追踪数据，endpoint 形如 "localhost:4317"
+func WithOtlpGrpc(endpoint string) Option {
+	return func(o *options) {
+		o.traceProtocol = ProtocolGrpc
+		o.traceEndpoint = endpoint
+	}
+}
+
+// WithOtlpHttp 通过 OTLP HTTP 导出链路追踪数据，endpoint 形如 "localhost:4318"
+func WithOtlpHttp(endpoint string) Option {
+	return func(o *options) {
+		o.traceProtocol = ProtocolHttp
+		o.traceEndpoint = endpoint
+	}
+}
+
+// WithTraceInsecure 使用非 TLS 连接导出链路追踪数据
+func WithTraceInsecure() Option {
+	return func(o *options) {
+		o.traceInsecure = true
+	}
+}
+
+// WithTraceSampleRatio 设置链路追踪采样率，取值 0 ~ 1
+func WithTraceSampleRatio(ratio float64) Option {
+	return func(o *options) {
+		o.traceSampleRatio = min(max(ratio, 0), 1)
+	}
+}
+
+// WithTraceBatch 设置批量导出的单批次最大 span 数和最长等待时间
+//
+// 参数 <= 0 时使用 SDK 默认值。
+func WithTraceBatch(size int, timeout time.Duration) Option {
+	return func(o *options) {
+		o.traceBatchSize = size
+		o.traceBatchTimeout = timeout
+	}
+}
+
+// WithBeforeStart 添加服务启动前执行的钩子
+func WithBeforeStart(hooks ...Hook) Option {
+	return func(o *options) {
+		o.beforeStart = append(o.beforeStart, hooks...)
+	}
+}
+
+// WithAfterStart 添加服务启动后执行的钩子
+func WithAfterStart(hooks ...Hook) Option {
+	return func(o *options) {
+		o.afterStart = append(o.afterStart, hooks...)
+	}
+}
+
+// WithBeforeStop 添加服务停止前执行的钩子
+func WithBeforeStop(hooks ...Hook) Option {
+	return func(o *options) {
+		o.beforeStop = append(o.beforeStop, hooks...)
+	}
+}
+
+// WithAfterStop 添加服务停止后执行的钩子
+func WithAfterStop(hooks ...Hook) Option {
+	return func(o *options) {
+		o.afterStop = append(o.afterStop, hooks...)
+	}
+}
+
+// WithShutdownTimeout 设置优雅关闭的最长等待时间，d <= 0 表示不限制
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.shutdownTimeout = d
+	}
+}
+
+// WithSecretResolver 添加自定义的配置密钥解析器，按添加顺序优先于内置解析器
+func WithSecretResolver(resolvers ...SecretResolver) Option {
+	return func(o *options) {
+		o.secretResolvers = append(o.secretResolvers, resolvers...)
+	}
+}
+
+// WithDisableHealth 禁止 NewApp 注册 /healthz、/readyz 和 grpc.health.v1
+func WithDisableHealth() Option {
+	return func(o *options) {
+		o.enableHealth = false
+	}
+}
+
+// WithMetrics 让 NewApp 在所有 HTTP Server 上挂载 /metrics，默认不挂载
+//
+// 指标没有鉴权，对外暴露的 HTTP Server 不要开启，
+// 可以改为在仅内网可达的 Server 上手动挂载 metricsx.Handler()。
+func WithMetrics() Option {
+	return func(o *options) {
+		o.enableMetrics = true
+	}
+}
@@ -0,0 +1,398 @@
+package bootstrap
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	logDayLayout = "2006-01-02"
+	logExt       = ".log"
+	gzipExt      = ".gz"
+)
+
+// dailyRotateWriter 按日期分割的日志写入器
+//
+// 文件命名：
+//   - 2006-01-02.log    当天第一个分段
+//   - 2006-01-02.1.log  超过 maxSize 后的后续分段
+//   - 2006-01-02.1.log.gz 开启压缩后已关闭的分段
+type dailyRotateWriter struct {
+	dir          string
+	maxDays      int   // 最大保留天数，<=0 不限制
+	maxSize      int64 // 单个文件最大字节数，<=0 不按大小分割
+	maxTotalSize int64 // 日志目录总字节数上限，<=0 不限制
+	compress     bool  // 是否 gzip 压缩已关闭的分段
+
+	mu      sync.Mutex
+	bgMu    sync.Mutex     // 串行化后台压缩与清理
+	wg      sync.WaitGroup // 等待后台压缩完成
+	active  atomic.Value   // 正在写入的文件名，供后台清理跳过
+	file    *os.File
+	lastDay string
+	segment int   // 当前分段序号
+	size    int64 // 当前文件已写入字节数
+	closed  bool
+}
+
+func (w *dailyRotateWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, nil // 闭合后静默丢弃，避免影响 shutdown 流程
+	}
+
+	day := time.Now().Format(logDayLayout)
+
+	// 1. 日期切换或文件未打开
+	if day != w.lastDay || w.file == nil {
+		isNewDay := day != w.lastDay
+		closed, err := w.rotate(day)
+		if err != nil {
+			return 0, err
+		}
+		if isNewDay {
+			w.afterRotate(closed)
+		}
+	}
+
+	// 2. 超过单文件大小则切换到下一个分段
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		closed, err := w.rotateSegment()
+		if err != nil {
+			return 0, err
+		}
+		w.afterRotate(closed)
+	}
+
+	// 3. 尝试写入
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		// 4. 写入失败尝试重试一次（可能是文件被删或其它 IO 异常），只写入剩余部分
+		_, _ = os.Stderr.WriteString("write log failed, trying to reopen: " + err.Error() + "\n")
+		if err = w.reopen(); err != nil {
+			return n, err
+		}
+		m, err := w.file.Write(p[n:])
+		w.size += int64(m)
+		return n + m, err
+	}
+
+	return n, nil
+}
+
+// rotate 切换到指定日期的文件，返回被关闭的文件路径
+func (w *dailyRotateWriter) rotate(day string) (string, error) {
+	var closed string
+	if w.file != nil {
+		_, _ = fmt.Fprintf(w.file, "\n%s [ROTATE] Log rotated from %s to %s\n", time.Now().Format(time.DateTime), w.lastDay, day)
+		closed = w.closeFile()
+	}
+
+	return closed, w.open(day, w.nextSegment(day))
+}
+
+// rotateSegment 当天内按大小切换到下一个分段，返回被关闭的文件路径
+func (w *dailyRotateWriter) rotateSegment() (string, error) {
+	day, segment := w.lastDay, w.segment+1
+	closed := w.closeFile()
+	return closed, w.open(day, segment)
+}
+
+func (w *dailyRotateWriter) open(day string, segment int) error {
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory %s: %w", w.dir, err)
+	}
+	filename := filepath.Join(w.dir, segmentName(day, segment))
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", filename, err)
+	}
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+	w.file = f
+	w.active.Store(filepath.Base(filename))
+	w.lastDay = day
+	w.segment = segment
+	w.size = size
+	return nil
+}
+
+func (w *dailyRotateWriter) reopen() error {
+	day := time.Now().Format(logDayLayout)
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+	return w.open(day, w.nextSegment(day))
+}
+
+// closeFile 关闭当前文件，返回其路径
+func (w *dailyRotateWriter) closeFile() string {
+	if w.file == nil {
+		return ""
+	}
+	name := w.file.Name()
+	_ = w.file.Close()
+	w.file = nil
+	w.size = 0
+	return name
+}
+
+// nextSegment 返回当天应写入的分段序号
+//
+// 沿用当天最大的分段继续追加；如果该分段已被压缩或已写满，则使用下一个分段。
+func (w *dailyRotateWriter) nextSegment(day string) int {
+	files, err := os.ReadDir(w.dir)
+	if err != nil {
+		return 0
+	}
+
+	segment, compressed := -1, false
+	for _, f := range files {
+		lf, ok := parseLogName(f.Name())
+		if !ok || lf.day != day {
+			continue
+		}
+		switch {
+		case lf.segment > segment:
+			segment, compressed = lf.segment, lf.compressed
+		case lf.segment == segment:
+			compressed = compressed || lf.compressed
+		}
+	}
+
+	if segment < 0 {
+		return 0
+	}
+	if compressed {
+		return segment + 1
+	}
+	if w.maxSize > 0 {
+		info, err := os.Stat(filepath.Join(w.dir, segmentName(day, segment)))
+		if err == nil && info.Size() >= w.maxSize {
+			return segment + 1
+		}
+	}
+	return segment
+}
+
+// afterRotate 切换文件后在后台压缩已关闭的文件并清理过期日志
+//
+// 先压缩再清理，避免按总大小清理时误删即将被压缩的文件。
+func (w *dailyRotateWriter) afterRotate(closed string) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.bgMu.Lock()
+		defer w.bgMu.Unlock()
+
+		if w.compress && closed != "" {
+			// 文件可能已被清理，忽略不存在的错误
+			if err := gzipFile(closed); err != nil && !os.IsNotExist(err) {
+				_, _ = os.Stderr.WriteString("compress log failed: " + err.Error() + "\n")
+			}
+		}
+		active, _ := w.active.Load().(string)
+		cleanOldLogs(w.dir, w.maxDays, w.maxTotalSize, active)
+	}()
+}
+
+func (w *dailyRotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// Close 关闭日志文件，并等待后台压缩完成
+func (w *dailyRotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.wg.Wait()
+	return err
+}
+
+// logFile 日志目录下的一个日志文件
+type logFile struct {
+	name       string
+	day        string
+	segment    int
+	compressed bool
+	size       int64
+}
+
+// segmentName 返回分段文件名，第 0 段不带序号
+func segmentName(day string, segment int) string {
+	if segment <= 0 {
+		return day + logExt
+	}
+	return day + "." + strconv.Itoa(segment) + logExt
+}
+
+// parseLogName 解析日志文件名，支持：
+// 2006-01-02.log、2006-01-02.1.log 以及对应的 .gz 文件
+func parseLogName(name string) (logFile, bool) {
+	lf := logFile{name: name}
+
+	base := name
+	if strings.HasSuffix(base, gzipExt) {
+		base = strings.TrimSuffix(base, gzipExt)
+		lf.compressed = true
+	}
+	if !strings.HasSuffix(base, logExt) {
+		return lf, false
+	}
+	base = strings.TrimSuffix(base, logExt)
+
+	if len(base) < len(logDayLayout) {
+		return lf, false
+	}
+	day, rest := base[:len(logDayLayout)], base[len(logDayLayout):]
+	if _, err := time.Parse(logDayLayout, day); err != nil {
+		return lf, false
+	}
+	lf.day = day
+
+	if rest == "" {
+		return lf, true
+	}
+	if rest[0] != '.' {
+		return lf, false
+	}
+	segment, err := strconv.Atoi(rest[1:])
+	if err != nil || segment <= 0 {
+		return lf, false
+	}
+	lf.segment = segment
+	return lf, true
+}
+
+// cleanOldLogs 清理过期日志
+//
+// 先删除超过 maxDays 的日志，再按从旧到新的顺序删除，直到总大小不超过 maxTotalSize。
+// active 为正在写入的文件名，永远不会被删除。
+func cleanOldLogs(dir string, maxDays int, maxTotalSize int64, active string) {
+	if maxDays <= 0 && maxTotalSize <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var cutoff string
+	if maxDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -maxDays).Format(logDayLayout)
+	}
+
+	files := make([]logFile, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		lf, ok := parseLogName(e.Name())
+		if !ok {
+			continue
+		}
+		if cutoff != "" && lf.day < cutoff && lf.name != active {
+			_ = os.Remove(filepath.Join(dir, lf.name))
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			lf.size = info.Size()
+		}
+		files = append(files, lf)
+	}
+
+	if maxTotalSize <= 0 {
+		return
+	}
+
+	var total int64
+	for _, lf := range files {
+		total += lf.size
+	}
+	if total <= maxTotalSize {
+		return
+	}
+
+	// 按日期、分段从旧到新排序
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].day != files[j].day {
+			return files[i].day < files[j].day
+		}
+		return files[i].segment < files[j].segment
+	})
+
+	for _, lf := range files {
+		if total <= maxTotalSize {
+			return
+		}
+		if lf.name == active {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, lf.name)); err == nil {
+			total -= lf.size
+		}
+	}
+}
+
+// gzipFile 将文件压缩为 .gz 并删除原文件
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	tmp := path + gzipExt + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, path+gzipExt); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,175 @@
+package bootstrap
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name string, size int) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func TestParseLogName(t *testing.T) {
+	cases := []struct {
+		name       string
+		ok         bool
+		segment    int
+		compressed bool
+	}{
+		{"2026-01-02.log", true, 0, false},
+		{"2026-01-02.3.log", true, 3, false},
+		{"2026-01-02.3.log.gz", true, 3, true},
+		{"2026-01-02.log.gz", true, 0, true},
+		{"2026-01-02.0.log", false, 0, false},
+		{"2026-01-02.x.log", false, 0, false},
+		{"2026-01-02-1.log", false, 0, false},
+		{"2026-13-02.log", false, 0, false},
+		{"2026-01-02.log.gz.tmp", false, 0, false},
+		{"app.log", false, 0, false},
+	}
+	for _, c := range cases {
+		lf, ok := parseLogName(c.name)
+		if ok != c.ok || ok && (lf.day != "2026-01-02" || lf.segment != c.segment || lf.compressed != c.compressed) {
+			t.Errorf("parseLogName(%q) = %+v, %v", c.name, lf, ok)
+		}
+		if ok && !lf.compressed && segmentName(lf.day, lf.segment) != c.name {
+			t.Errorf("segmentName does not round trip %q", c.name)
+		}
+	}
+}
+
+func TestNextSegment(t *testing.T) {
+	dir := t.TempDir()
+	w := &dailyRotateWriter{dir: dir, maxSize: 10}
+	day := "2026-01-02"
+
+	if got := w.nextSegment(day); got != 0 {
+		t.Fatalf("empty dir: %d", got)
+	}
+	writeFile(t, dir, "2026-01-02.log", 4)
+	writeFile(t, dir, "2026-01-01.5.log", 0)
+	if got := w.nextSegment(day); got != 0 {
+		t.Fatalf("append to unfinished segment: %d", got)
+	}
+	writeFile(t, dir, "2026-01-02.1.log", 10)
+	if got := w.nextSegment(day); got != 2 {
+		t.Fatalf("full segment: %d", got)
+	}
+	writeFile(t, dir, "2026-01-02.2.log.gz", 1)
+	if got := w.nextSegment(day); got != 3 {
+		t.Fatalf("compressed segment: %d", got)
+	}
+}
+
+func TestRotateWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	w := &dailyRotateWriter{dir: dir, maxSize: 10}
+	defer w.Close()
+
+	for _, s := range []string{"hello\n", "world\n", "again\n"} {
+		if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write() = %d, %v", n, err)
+		}
+	}
+	day := time.Now().Format(logDayLayout)
+	want := []string{day + ".1.log", day + ".2.log", day + ".log"}
+	if got := listDir(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	// 文件被意外关闭后重新打开继续写入
+	_ = w.file.Close()
+	if n, err := w.Write([]byte("x\n")); err != nil || n != 2 {
+		t.Fatalf("Write() after close = %d, %v", n, err)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, day+".2.log"))
+	if string(b) != "again\nx\n" {
+		t.Fatalf("unexpected content %q", b)
+	}
+}
+
+func TestGzipFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2026-01-02.log")
+	if err := os.WriteFile(path, []byte("line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); !slices.Equal(got, []string{"2026-01-02.log.gz"}) {
+		t.Fatalf("files = %v", got)
+	}
+
+	f, err := os.Open(path + gzipExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != "line\n" {
+		t.Fatalf("unexpected content %q", b)
+	}
+
+	if err := gzipFile(filepath.Join(dir, "missing.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist, got %v", err)
+	}
+}
+
+func TestCleanOldLogs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().AddDate(0, 0, -10).Format(logDayLayout)
+	today := time.Now().Format(logDayLayout)
+
+	writeFile(t, dir, old+".log", 10)
+	writeFile(t, dir, old+".1.log.gz", 10)
+	writeFile(t, dir, "other.txt", 10)
+	writeFile(t, dir, today+".log", 10)
+	writeFile(t, dir, today+".1.log", 10)
+	writeFile(t, dir, today+".2.log", 10)
+
+	// 超过保留天数的删除，active 永远保留
+	cleanOldLogs(dir, 7, 0, old+".log")
+	want := []string{old + ".log", today + ".1.log", today + ".2.log", today + ".log", "other.txt"}
+	if got := listDir(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("maxDays: files = %v, want %v", got, want)
+	}
+
+	// 按总大小从旧到新删除，跳过 active
+	cleanOldLogs(dir, 0, 25, today+".log")
+	want = []string{today + ".2.log", today + ".log", "other.txt"}
+	if got := listDir(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("maxTotalSize: files = %v, want %v", got, want)
+	}
+
+	// active 单独超过上限时也不删除
+	cleanOldLogs(dir, 0, 1, today+".log")
+	want = []string{today + ".log", "other.txt"}
+	if got := listDir(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("active: files = %v, want %v", got, want)
+	}
+}
@@ -2,21 +2,28 @@ package bootstrap
 
 import (
 	"flag"
+	"os"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/log"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/trace"
 )
 
 // runner 定义了 main 函数中 wire 注入的逻辑
 // T 是每个项目自定义的 Bootstrap 配置结构体类型
 type runner[T any] func(cfg T, logger log.Logger) (*kratos.App, func(), error)
 
-// Run 执行通用的启动流程
+// Run 执行通用的启动流程，失败时按阶段以非零退出码退出进程
 func Run[T any](cfg T, run runner[T]) {
+	if err := RunE(cfg, run); err != nil {
+		os.Exit(ExitCode(err))
+	}
+}
 
+// RunE 执行通用的启动流程并返回错误
+//
+// 清理顺序固定为：业务 cleanup -> 配置监听 -> 链路追踪 -> 日志。
+// 返回的错误为 *RunError，可通过 ExitCode 获取对应的退出码。
+func RunE[T any](cfg T, run runner[T]) error {
 	Init()
 
 	if !flag.Parsed() {
@@ -24,30 +31,59 @@ func Run[T any](cfg T, run runner[T]) {
 	}
 	updateConfDir()
 
-	if globalOption.enableTrace || globalOption.enableSpan {
-		// 启用链路追踪
-		tp := trace.NewTracerProvider()
-		otel.SetTracerProvider(tp)
-		otel.SetTextMapPropagator(propagation.TraceContext{})
-	}
+	return runApp(cfg, run)
+}
 
+// runApp 执行 Init 和命令行解析之后的启动流程，不依赖进程级的一次性初始化
+func runApp[T any](cfg T, run runner[T]) (err error) {
 	logger, loggerCleanup := newLogger()
 	defer loggerCleanup()
 
-	// 加载配置到传入的泛型结构体
-	if err := loadConfig(cfg); err != nil {
-		panic(err)
+	// 输出 env 文件的加载结果
+	logEnv(logger)
+
+	stopLevelSignals := watchLevelSignals(logger)
+	defer stopLevelSignals()
+
+	// 在日志关闭前记录启动流程的错误
+	defer func() {
+		if err != nil {
+			_ = logger.Log(log.LevelError, "msg", "service exited", "error", err, "exit_code", ExitCode(err))
+		}
+	}()
+
+	if globalOption.enableTrace || globalOption.enableSpan || globalOption.traceEndpoint != "" {
+		// 启用链路追踪，退出时刷出未上报的 span
+		traceCleanup, err := newTracerProvider(logger)
+		if err != nil {
+			return newRunError(StageTracing, err)
+		}
+		defer traceCleanup()
 	}
 
+	// 加载配置到传入的泛型结构体，并在应用生命周期内监听变化
+	configCleanup, err := loadConfig(cfg, logger)
+	if err != nil {
+		return newRunError(StageConfig, err)
+	}
+	defer configCleanup()
+
+	// 配置中的密钥登记后再输出变量，便于一并脱敏
+	dumpEnv(logger)
+
 	// 执行业务注入逻辑 (调用 main 里的 wireApp)
 	app, cleanup, err := run(cfg, logger)
 	if err != nil {
-		panic(err)
+		return newRunError(StageWire, err)
+	}
+	if cleanup != nil {
+		defer cleanup()
 	}
-	defer cleanup()
 
 	// 运行服务
 	if err := app.Run(); err != nil {
-		panic(err)
+		return newRunError(StageRuntime, err)
 	}
+
+	return nil
 }
@@ -0,0 +1,235 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// failServer 启动即失败的服务
+type failServer struct{}
+
+func (failServer) Start(context.Context) error { return errors.New("listen: address already in use") }
+func (failServer) Stop(context.Context) error  { return nil }
+
+// lifecycleServer 记录启动和停止的服务，停止时最多等待 stopDelay
+type lifecycleServer struct {
+	record    func(event string)
+	stopDelay time.Duration
+	stopped   chan struct{}
+}
+
+func newLifecycleServer(record func(string), stopDelay time.Duration) *lifecycleServer {
+	return &lifecycleServer{record: record, stopDelay: stopDelay, stopped: make(chan struct{})}
+}
+
+func (s *lifecycleServer) Start(context.Context) error {
+	s.record("server start")
+	<-s.stopped
+	return nil
+}
+
+func (s *lifecycleServer) Stop(ctx context.Context) error {
+	s.record("server stop")
+	defer close(s.stopped)
+	select {
+	case <-time.After(s.stopDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// eventLog 并发安全的事件记录
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) record(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) hook(event string) Hook {
+	return func(context.Context) error {
+		l.record(event)
+		return nil
+	}
+}
+
+func (l *eventLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.events)
+}
+
+// setupLifecycle 应用生命周期相关的 Option，测试结束后恢复
+func setupLifecycle(t *testing.T, opts ...Option) {
+	t.Helper()
+	o := globalOption
+	beforeStart, afterStart, beforeStop, afterStop := o.beforeStart, o.afterStart, o.beforeStop, o.afterStop
+	timeout, enableHealth, enableMetrics := o.shutdownTimeout, o.enableHealth, o.enableMetrics
+	t.Cleanup(func() {
+		o.beforeStart, o.afterStart, o.beforeStop, o.afterStop = beforeStart, afterStart, beforeStop, afterStop
+		o.shutdownTimeout, o.enableHealth, o.enableMetrics = timeout, enableHealth, enableMetrics
+	})
+	o.beforeStart, o.afterStart, o.beforeStop, o.afterStop = nil, nil, nil, nil
+	o.enableHealth, o.enableMetrics = false, false
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// stopAfterStart 返回一个在启动完成后停止 app 的钩子
+func stopAfterStart(app **kratos.App) Hook {
+	return func(context.Context) error {
+		go func() { _ = (*app).Stop() }()
+		return nil
+	}
+}
+
+type runConfig struct {
+	Name string `json:"name"`
+}
+
+// setupRun 准备 runApp 依赖的全局状态，测试结束后恢复
+func setupRun(t *testing.T, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o := globalOption
+	writer, enableFile, setGlobal, savedConf := o.writer, o.enableFile, o.setGlobal, FlagConf
+	enableTrace, enableSpan, endpoint, protocol := o.enableTrace, o.enableSpan, o.traceEndpoint, o.traceProtocol
+	t.Cleanup(func() {
+		o.writer, o.enableFile, o.setGlobal, FlagConf = writer, enableFile, setGlobal, savedConf
+		o.enableTrace, o.enableSpan, o.traceEndpoint, o.traceProtocol = enableTrace, enableSpan, endpoint, protocol
+	})
+	o.writer, o.enableFile, o.setGlobal = io.Discard, false, false
+	o.enableTrace, o.enableSpan, o.traceEndpoint = false, false, ""
+	FlagConf = dir
+}
+
+func TestRunExitCode(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		setup  func()
+		run    runner[*runConfig]
+		want   int
+	}{
+		{"config", "name: [", nil, nil, ExitConfig},
+		{"tracing", "name: a", func() {
+			globalOption.traceEndpoint, globalOption.traceProtocol = "127.0.0.1:4318", "udp"
+		}, nil, ExitTracing},
+		{"wire", "name: a", nil, func(*runConfig, log.Logger) (*kratos.App, func(), error) {
+			return nil, nil, errors.New("wire failed")
+		}, ExitWire},
+		{"runtime", "name: a", nil, func(*runConfig, log.Logger) (*kratos.App, func(), error) {
+			return kratos.New(kratos.Server(failServer{})), nil, nil
+		}, ExitRuntime},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setupRun(t, c.config)
+			if c.setup != nil {
+				c.setup()
+			}
+			err := runApp(&runConfig{}, c.run)
+			var re *RunError
+			if !errors.As(err, &re) || re.Stage != c.name || ExitCode(err) != c.want {
+				t.Fatalf("runApp() = %v, exit code %d, want %d", err, ExitCode(err), c.want)
+			}
+		})
+	}
+
+	if ExitCode(nil) != ExitOK || ExitCode(errors.New("boom")) != ExitUnknown {
+		t.Fatal("unexpected exit code for nil or unclassified error")
+	}
+	if code := ExitCode(newRunError("other", errors.New("boom"))); code != ExitUnknown {
+		t.Fatalf("unknown stage exit code = %d", code)
+	}
+}
+
+func TestRunLifecycleOrder(t *testing.T) {
+	setupRun(t, "name: a")
+	events := &eventLog{}
+	var app *kratos.App
+	setupLifecycle(t,
+		WithBeforeStart(events.hook("before start 1"), events.hook("before start 2")),
+		WithAfterStart(events.hook("after start"), stopAfterStart(&app)),
+		WithBeforeStop(events.hook("before stop")),
+		WithAfterStop(events.hook("after stop")),
+	)
+
+	err := runApp(&runConfig{}, func(_ *runConfig, logger log.Logger) (*kratos.App, func(), error) {
+		app = NewApp(logger, newLifecycleServer(events.record, 0))
+		return app, func() { events.record("cleanup") }, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务在独立的协程中启动，只保证在 before start 之后、server stop 之前
+	got := events.list()
+	start := slices.Index(got, "server start")
+	if start < 2 || start > slices.Index(got, "server stop") {
+		t.Fatalf("unexpected server start position: %q", got)
+	}
+	got = slices.Delete(got, start, start+1)
+	want := []string{"before start 1", "before start 2", "after start", "before stop", "server stop", "after stop", "cleanup"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestRunBeforeStartFailure(t *testing.T) {
+	setupRun(t, "name: a")
+	events := &eventLog{}
+	setupLifecycle(t,
+		WithBeforeStart(func(context.Context) error { return errors.New("migrate failed") }),
+		WithAfterStart(events.hook("after start")),
+	)
+
+	err := runApp(&runConfig{}, func(_ *runConfig, logger log.Logger) (*kratos.App, func(), error) {
+		return NewApp(logger, newLifecycleServer(events.record, 0)), nil, nil
+	})
+	var re *RunError
+	if !errors.As(err, &re) || re.Stage != StageRuntime || ExitCode(err) != ExitRuntime {
+		t.Fatalf("runApp() = %v, exit code %d, want %d", err, ExitCode(err), ExitRuntime)
+	}
+	if got := events.list(); len(got) != 0 {
+		t.Fatalf("nothing should start after a failed before start hook, got %q", got)
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	setupRun(t, "name: a")
+	events := &eventLog{}
+	var app *kratos.App
+	setupLifecycle(t, WithShutdownTimeout(50*time.Millisecond), WithAfterStart(stopAfterStart(&app)))
+
+	start := time.Now()
+	err := runApp(&runConfig{}, func(_ *runConfig, logger log.Logger) (*kratos.App, func(), error) {
+		app = NewApp(logger, newLifecycleServer(events.record, 10*time.Second))
+		return app, nil, nil
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("shutdown took %s, timeout is 50ms", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || ExitCode(err) != ExitRuntime {
+		t.Fatalf("runApp() = %v, want deadline exceeded with exit code %d", err, ExitRuntime)
+	}
+}
@@ -0,0 +1,338 @@
+package bootstrap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/constants"
+)
+
+// 内置的密钥引用前缀
+const (
+	secretFilePrefix = "file://"
+	secretEnvPrefix  = "env://"
+	secretEncPrefix  = "enc:"
+)
+
+// secretMask 日志中用于替换密钥的占位符
+const secretMask = "******"
+
+// 密文格式：salt(16) | nonce(12) | ciphertext，AES-256 密钥由 PBKDF2-SHA256 派生
+const secretSaltLen = 16
+
+// secretKDFIter PBKDF2 迭代次数
+var secretKDFIter = 600_000
+
+// SecretResolver 解析配置中的密钥引用
+//
+// ok 为 false 表示 ref 不是该解析器支持的格式，会继续交给下一个解析器。
+type SecretResolver interface {
+	Resolve(ref string) (value string, ok bool, err error)
+}
+
+// SecretResolverFunc 函数形式的 SecretResolver
+type SecretResolverFunc func(ref string) (string, bool, error)
+
+func (f SecretResolverFunc) Resolve(ref string) (string, bool, error) {
+	return f(ref)
+}
+
+// fileSecretResolver 读取文件内容，如 file:///run/secrets/db
+type fileSecretResolver struct{}
+
+func (fileSecretResolver) Resolve(ref string) (string, bool, error) {
+	path, ok := strings.CutPrefix(ref, secretFilePrefix)
+	if !ok {
+		return "", false, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", true, fmt.Errorf("read secret file %s: %w", path, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+// envSecretResolver 读取环境变量，如 env://DB_PASS
+type envSecretResolver struct{}
+
+func (envSecretResolver) Resolve(ref string) (string, bool, error) {
+	name, ok := strings.CutPrefix(ref, secretEnvPrefix)
+	if !ok {
+		return "", false, nil
+	}
+	v, exists := os.LookupEnv(name)
+	if !exists {
+		return "", true, fmt.Errorf("secret env %s not set", name)
+	}
+	return v, true, nil
+}
+
+// encSecretResolver 解密 AES-GCM 密文，如 enc:<base64>
+//
+// 密钥由环境变量 constants.SecretKey 经 PBKDF2 派生。
+type encSecretResolver struct{}
+
+func (encSecretResolver) Resolve(ref string) (string, bool, error) {
+	blob, ok := strings.CutPrefix(ref, secretEncPrefix)
+	if !ok {
+		return "", false, nil
+	}
+	key := os.Getenv(constants.SecretKey)
+	if key == "" {
+		return "", true, fmt.Errorf("%s is required to decrypt secrets", constants.SecretKey)
+	}
+	v, err := DecryptSecret(blob, key)
+	return v, true, err
+}
+
+// secretResolvers 返回生效的解析器，自定义解析器优先
+func secretResolvers() []SecretResolver {
+	return append(slices.Clone(globalOption.secretResolvers),
+		fileSecretResolver{},
+		envSecretResolver{},
+		encSecretResolver{},
+	)
+}
+
+// resolveSecrets 替换配置中所有字符串形式的密钥引用，并登记用于日志脱敏
+//
+// skip 中的顶层 key 不做解析。
+func resolveSecrets(input map[string]any, skip map[string]bool) error {
+	resolvers := secretResolvers()
+
+	var errs []error
+	var walk func(path string, v any) any
+	walk = func(path string, v any) any {
+		switch t := v.(type) {
+		case string:
+			for _, r := range resolvers {
+				value, ok, err := r.Resolve(t)
+				if !ok {
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", path, err))
+					return t
+				}
+				secrets.add(value)
+				return value
+			}
+			return t
+		case map[string]any:
+			for k, sub := range t {
+				if path == "" && skip[k] {
+					continue
+				}
+				t[k] = walk(joinPath(path, k), sub)
+			}
+			return t
+		case []any:
+			for i, sub := range t {
+				t[i] = walk(fmt.Sprintf("%s[%d]", path, i), sub)
+			}
+			return t
+		default:
+			return v
+		}
+	}
+
+	walk("", input)
+	return errors.Join(errs...)
+}
+
+// envOnlyKeys 返回值直接来自环境变量配置源、没有被配置文件覆盖的顶层 key
+//
+// 环境变量配置源没有前缀，会把所有进程环境变量作为顶层 key 合并进来，
+// 这些值按字面量处理，不解析其中的 file://、env://、enc: 引用。
+func envOnlyKeys(input map[string]any) map[string]bool {
+	skip := make(map[string]bool)
+	for k, v := range input {
+		if s, ok := v.(string); ok {
+			if env, exists := os.LookupEnv(k); exists && env == s {
+				skip[k] = true
+			}
+		}
+	}
+	return skip
+}
+
+// resolveConfig 配置解析流程：先插值，再解析配置文件中的密钥引用
+func resolveConfig(input map[string]any) error {
+	skip := envOnlyKeys(input)
+	interpolate(input)
+	return resolveSecrets(input, skip)
+}
+
+/************************
+ * Encrypt
+ ************************/
+
+// secretKeys 缓存派生出的密钥，避免每次重新加载配置都重复计算 PBKDF2
+var secretKeys sync.Map // map[string][]byte，key 为 salt + 密码
+
+// secretCipher 使用 PBKDF2-SHA256 从任意长度的 key 和 salt 派生 AES-256-GCM
+func secretCipher(key string, salt []byte) (cipher.AEAD, error) {
+	cacheKey := string(salt) + key
+	derived, ok := secretKeys.Load(cacheKey)
+	if !ok {
+		dk, err := pbkdf2.Key(sha256.New, key, salt, secretKDFIter, 32)
+		if err != nil {
+			return nil, err
+		}
+		derived, _ = secretKeys.LoadOrStore(cacheKey, dk)
+	}
+	block, err := aes.NewCipher(derived.([]byte))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret 使用 AES-GCM 加密明文，返回可直接写入配置的 enc:<base64> 字符串
+//
+// 每次加密使用随机的 salt 和 nonce，相同明文的密文也不相同。
+func EncryptSecret(plaintext, key string) (string, error) {
+	if key == "" {
+		return "", errors.New("encrypt secret: empty key")
+	}
+	salt := make([]byte, secretSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := secretCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(append(salt, nonce...), nonce, []byte(plaintext), nil)
+	return secretEncPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的密文，blob 可以带或不带 enc: 前缀
+func DecryptSecret(blob, key string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(blob, secretEncPrefix))
+	if err != nil {
+		return "", fmt.Errorf("decode secret: %w", err)
+	}
+	if len(data) < secretSaltLen {
+		return "", errors.New("decrypt secret: ciphertext too short")
+	}
+	salt, data := data[:secretSaltLen], data[secretSaltLen:]
+	gcm, err := secretCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return "", errors.New("decrypt secret: ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+/************************
+ * Mask
+ ************************/
+
+// secretSet 已解析的密钥集合，用于日志脱敏
+type secretSet struct {
+	mu     sync.Mutex
+	values atomic.Pointer[[]string]
+}
+
+var secrets = &secretSet{}
+
+// add 登记一个需要脱敏的密钥，无论长短都脱敏，宁可误伤普通文本也不泄露密钥
+func (s *secretSet) add(v string) {
+	// 空字符串无法替换
+	if v == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cur []string
+	if p := s.values.Load(); p != nil {
+		cur = *p
+	}
+	if slices.Contains(cur, v) {
+		return
+	}
+	next := append(slices.Clone(cur), v)
+	// 长的优先替换，避免包含关系导致部分泄露
+	slices.SortFunc(next, func(a, b string) int { return len(b) - len(a) })
+	s.values.Store(&next)
+}
+
+// list 返回已登记的密钥
+func (s *secretSet) list() []string {
+	if p := s.values.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// MaskSecrets 把字符串中出现的已解析密钥替换为 ******
+func MaskSecrets(s string) string {
+	for _, v := range secrets.list() {
+		if strings.Contains(s, v) {
+			s = strings.ReplaceAll(s, v, secretMask)
+		}
+	}
+	return s
+}
+
+// maskLogger 输出前对已解析的配置密钥脱敏
+type maskLogger struct {
+	logger log.Logger
+}
+
+// MaskLogger 包装 logger，把日志字段中出现的已解析密钥替换为 ******
+//
+// bootstrap 创建的日志器已经包装过，自行创建的日志器需要调用该方法。
+func MaskLogger(logger log.Logger) log.Logger {
+	return &maskLogger{logger: logger}
+}
+
+func (l *maskLogger) Log(level log.Level, keyvals ...any) error {
+	if len(secrets.list()) > 0 {
+		masked := make([]any, len(keyvals))
+		for i, v := range keyvals {
+			masked[i] = maskValue(v)
+		}
+		keyvals = masked
+	}
+	return l.logger.Log(level, keyvals...)
+}
+
+// maskValue 对日志字段值脱敏，不包含密钥时原样返回
+func maskValue(v any) any {
+	switch v.(type) {
+	case string, []byte, error, fmt.Stringer:
+	default:
+		return v
+	}
+	s := toString(v)
+	if masked := MaskSecrets(s); masked != s {
+		return masked
+	}
+	return v
+}
@@ -0,0 +1,150 @@
+package bootstrap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/constants"
+)
+
+// fastKDF 降低测试中的 PBKDF2 迭代次数
+func fastKDF(t *testing.T) {
+	iter := secretKDFIter
+	secretKDFIter = 1000
+	t.Cleanup(func() { secretKDFIter = iter })
+}
+
+func TestEncryptSecret(t *testing.T) {
+	fastKDF(t)
+	blob, err := EncryptSecret("s3cret-pass", "master-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(blob, secretEncPrefix) {
+		t.Fatalf("unexpected blob %s", blob)
+	}
+	other, _ := EncryptSecret("s3cret-pass", "master-key")
+	if other == blob {
+		t.Fatal("expected random salt and nonce")
+	}
+
+	truncated := blob[:len(secretEncPrefix)+8]
+	cases := []struct {
+		name, blob, key, want string
+		wantErr               bool
+	}{
+		{"round trip", blob, "master-key", "s3cret-pass", false},
+		{"without prefix", strings.TrimPrefix(blob, secretEncPrefix), "master-key", "s3cret-pass", false},
+		{"bad key", blob, "wrong-key", "", true},
+		{"bad base64", "enc:%%%", "master-key", "", true},
+		{"too short", truncated, "master-key", "", true},
+	}
+	for _, c := range cases {
+		got, err := DecryptSecret(c.blob, c.key)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("%s: got %q, %v", c.name, got, err)
+		}
+	}
+
+	if _, err := EncryptSecret("s3cret-pass", ""); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	fastKDF(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db"), []byte("file-pass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := EncryptSecret("enc-pass", "master-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(constants.SecretKey, "master-key")
+	t.Setenv("TEST_SECRET_DB_PASS", "env-pass")
+	t.Setenv("TEST_SECRET_REF", "env://TEST_SECRET_DB_PASS")
+	t.Setenv("TEST_SECRET_LITERAL", "file://"+filepath.Join(dir, "db"))
+
+	resolvers := globalOption.secretResolvers
+	t.Cleanup(func() { globalOption.secretResolvers = resolvers })
+	globalOption.secretResolvers = []SecretResolver{SecretResolverFunc(func(ref string) (string, bool, error) {
+		name, ok := strings.CutPrefix(ref, "vault://")
+		return "vault-" + name, ok, nil
+	})}
+
+	cases := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"file", "file://" + filepath.Join(dir, "db"), "file-pass", false},
+		{"env", "env://TEST_SECRET_DB_PASS", "env-pass", false},
+		{"enc", blob, "enc-pass", false},
+		{"custom", "vault://db", "vault-db", false},
+		{"interpolated", "${TEST_SECRET_REF}", "env-pass", false},
+		{"plain", "root:pass@tcp(127.0.0.1)/db", "root:pass@tcp(127.0.0.1)/db", false},
+		{"missing file", "file://" + filepath.Join(dir, "nope"), "", true},
+		{"missing env", "env://TEST_SECRET_MISSING", "", true},
+	}
+
+	for _, c := range cases {
+		input := map[string]any{"data": map[string]any{"list": []any{c.value}}}
+		err := resolveConfig(input)
+		if c.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "data.list[0]") {
+				t.Errorf("%s: expected error with path, got %v", c.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got := input["data"].(map[string]any)["list"].([]any)[0]; got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	// 只来自环境变量配置源的值按字面量处理，配置文件覆盖后的同名 key 正常解析
+	input := map[string]any{
+		"TEST_SECRET_LITERAL": "file://" + filepath.Join(dir, "db"),
+		"TEST_SECRET_DB_PASS": "env://TEST_SECRET_DB_PASS",
+	}
+	if err := resolveConfig(input); err != nil {
+		t.Fatal(err)
+	}
+	if input["TEST_SECRET_LITERAL"] != "file://"+filepath.Join(dir, "db") || input["TEST_SECRET_DB_PASS"] != "env-pass" {
+		t.Fatalf("unexpected values: %v", input)
+	}
+}
+
+func TestMaskLogger(t *testing.T) {
+	secrets.add("mask-pass-123")
+	secrets.add("z9") // 短密钥同样脱敏
+	secrets.add("")
+
+	buf := &bytes.Buffer{}
+	logger := MaskLogger(log.NewStdLogger(buf))
+	_ = logger.Log(log.LevelInfo,
+		"dsn", "root:mask-pass-123@tcp(127.0.0.1)/db",
+		"err", os.ErrNotExist,
+		"short", "z9",
+		"n", 42,
+	)
+	got := buf.String()
+	if strings.Contains(got, "mask-pass-123") || !strings.Contains(got, "root:******@tcp") {
+		t.Fatalf("secret not masked: %s", got)
+	}
+	if !strings.Contains(got, "short=******") || !strings.Contains(got, "n=42") {
+		t.Fatalf("unexpected output: %s", got)
+	}
+	if MaskSecrets("x mask-pass-123 y") != "x ****** y" || MaskSecrets("plain") != "plain" {
+		t.Fatal("MaskSecrets did not mask")
+	}
+}
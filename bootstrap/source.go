@@ -0,0 +1,161 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/env"
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/lhlyu/kratos-easy/constants"
+)
+
+// 基础配置文件名
+const (
+	baseConfName = "config"
+	baseConfExt  = ".yaml"
+)
+
+// configLayer 一层配置来源，越靠后优先级越高
+type configLayer struct {
+	name   string
+	source config.Source
+}
+
+// currentProfile 返回当前的配置 profile，本地调试且未设置 APP_ENV 时为 local
+func currentProfile() string {
+	profile := constants.CurrentEnv()
+	if profile == "" && constants.IsLocal() {
+		profile = constants.EnvLocal
+	}
+	return profile
+}
+
+// configLayers 按优先级从低到高返回配置来源
+//
+//   - 环境变量
+//   - 基础配置 config.yaml
+//   - profile 配置 config.<APP_ENV>.yaml，与基础配置深度合并
+//
+// path 为文件时以该文件为基础配置，同目录下的 <name>.<APP_ENV><ext> 为 profile 配置；
+// path 为目录且不存在 config.yaml 时，只加载当前 profile 的配置文件。
+func configLayers(path, profile string) ([]configLayer, error) {
+	base, overlay, err := resolveConfigFiles(path, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []configLayer{
+		{name: "env", source: env.NewSource()},
+		{name: base, source: file.NewSource(base)},
+	}
+	if overlay != "" {
+		layers = append(layers, configLayer{name: overlay, source: file.NewSource(overlay)})
+	}
+	return layers, nil
+}
+
+// resolveConfigFiles 返回基础配置文件和 profile 配置文件，profile 配置不存在时 overlay 为空
+//
+// path 为目录且不存在 config.yaml 时，以 config.<profile>.yaml 作为唯一的配置文件，
+// 两者都不存在时返回错误，不会把目录下的所有配置文件按不确定的顺序合并。
+func resolveConfigFiles(path, profile string) (base, overlay string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("config path: %w", err)
+	}
+
+	if !info.IsDir() {
+		return path, profileFile(path, profile), nil
+	}
+
+	base = filepath.Join(path, baseConfName+baseConfExt)
+	if isFile(base) {
+		return base, profileFile(base, profile), nil
+	}
+	if only := profileFile(base, profile); only != "" {
+		return only, "", nil
+	}
+	if profile == "" {
+		return "", "", fmt.Errorf("config file %s not found", base)
+	}
+	return "", "", fmt.Errorf("config file %s or %s not found in %s",
+		baseConfName+baseConfExt, baseConfName+"."+profile+baseConfExt, path)
+}
+
+// profileFile 返回 base 对应的 profile 配置文件，不存在时返回空字符串
+func profileFile(base, profile string) string {
+	if profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(base)
+	overlay := strings.TrimSuffix(base, ext) + "." + profile + ext
+	if !isFile(overlay) {
+		return ""
+	}
+	return overlay
+}
+
+// isFile 判断路径是否为存在的普通文件
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+/************************
+ * Interpolation
+ ************************/
+
+// placeholder 匹配 ${VAR} 或 ${VAR:default}
+var placeholder = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// interpolate 替换配置中所有字符串值里的 ${VAR:default} 占位符
+//
+// 查找顺序：进程环境变量 -> 已合并配置中的同名 key（支持点分路径）-> 默认值，都不存在时替换为空字符串。
+func interpolate(input map[string]any) {
+	lookupFn := func(name string) string {
+		key, def, hasDef := strings.Cut(strings.TrimSpace(name), ":")
+		if v, ok := os.LookupEnv(key); ok {
+			return v
+		}
+		if v, ok := lookup(input, key); ok {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+		if hasDef {
+			return def
+		}
+		return ""
+	}
+
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch t := v.(type) {
+		case string:
+			if !strings.Contains(t, "${") {
+				return t
+			}
+			return placeholder.ReplaceAllStringFunc(t, func(m string) string {
+				return lookupFn(m[2 : len(m)-1])
+			})
+		case map[string]any:
+			for k, sub := range t {
+				t[k] = walk(sub)
+			}
+			return t
+		case []any:
+			for i, sub := range t {
+				t[i] = walk(sub)
+			}
+			return t
+		default:
+			return v
+		}
+	}
+
+	walk(input)
+}
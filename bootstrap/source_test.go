@@ -0,0 +1,89 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfigFiles(t *testing.T) {
+	write := func(dir string, names ...string) string {
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	full := write(t.TempDir(), "config.yaml", "config.local.yaml", "config.test.yaml")
+	profileOnly := write(t.TempDir(), "config.local.yaml", "config.test.yaml")
+	empty := t.TempDir()
+	custom := write(t.TempDir(), "app.yml", "app.staging.yml")
+
+	cases := []struct {
+		name, path, profile string
+		base, overlay       string
+		wantErr             bool
+	}{
+		{"base and profile", full, "local", "config.yaml", "config.local.yaml", false},
+		{"missing profile", full, "production", "config.yaml", "", false},
+		{"no profile", full, "", "config.yaml", "", false},
+		{"profile only", profileOnly, "test", "config.test.yaml", "", false},
+		{"profile only without profile", profileOnly, "", "", "", true},
+		{"profile only with other profile", profileOnly, "production", "", "", true},
+		{"empty dir", empty, "local", "", "", true},
+		{"file", filepath.Join(custom, "app.yml"), "staging", "app.yml", "app.staging.yml", false},
+		{"missing path", filepath.Join(empty, "nope"), "local", "", "", true},
+	}
+	for _, c := range cases {
+		base, overlay, err := resolveConfigFiles(c.path, c.profile)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+		if filepath.Base(base) != filepath.Base(c.base) || filepath.Base(overlay) != filepath.Base(c.overlay) {
+			t.Fatalf("%s: got %q, %q", c.name, base, overlay)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("TEST_INTERP_HOST", "env-host")
+	t.Setenv("db.host", "env-override")
+
+	input := map[string]any{
+		"db": map[string]any{
+			"host": "config-host",
+			"user": "root",
+		},
+		"name": "demo",
+		"values": []any{
+			"${TEST_INTERP_HOST}",          // 环境变量
+			"${db.host:default-host}",      // 环境变量 > 配置
+			"${TEST_INTERP_HOST:fallback}", // 环境变量 > 默认值
+			"${db.user:guest}@${name}",     // 配置 > 默认值，支持多个占位符
+			"${TEST_INTERP_MISSING:8080}",  // 默认值
+			"${TEST_INTERP_MISSING}",       // 都不存在时为空
+			"${db.missing:a:b}",            // 默认值中可包含冒号
+			"$TEST_INTERP_HOST",            // 不是占位符
+			map[string]any{"nested": "${name}"},
+		},
+		"port": 8080,
+	}
+
+	interpolate(input)
+
+	want := []any{"env-host", "env-override", "env-host", "root@demo", "8080", "", "a:b", "$TEST_INTERP_HOST"}
+	got := input["values"].([]any)
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("values[%d] = %q, want %q", i, got[i], w)
+		}
+	}
+	if nested := got[len(want)].(map[string]any)["nested"]; nested != "demo" {
+		t.Errorf("nested = %q", nested)
+	}
+	if input["port"] != 8080 {
+		t.Errorf("non-string value changed: %v", input["port"])
+	}
+}
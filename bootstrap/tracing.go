@@ -0,0 +1,126 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// OTLP 导出协议
+const (
+	ProtocolGrpc = "grpc"
+	ProtocolHttp = "http"
+)
+
+// traceShutdownTimeout 关闭 TracerProvider 时等待数据刷出的最长时间
+const traceShutdownTimeout = 5 * time.Second
+
+// newTracerProvider 创建并注册全局 TracerProvider，返回清理函数
+//
+// 未配置导出地址时只生成 trace_id/span_id，不上报到任何后端。
+func newTracerProvider(logger log.Logger) (func(), error) {
+	ctx := context.Background()
+	l := log.NewHelper(logger)
+
+	res, err := newTraceResource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	tpOpts := []trace.TracerProviderOption{
+		trace.WithResource(res),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(globalOption.traceSampleRatio))),
+	}
+
+	if globalOption.traceEndpoint != "" {
+		exporter, err := newTraceExporter(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+		}
+
+		var batchOpts []trace.BatchSpanProcessorOption
+		if globalOption.traceBatchSize > 0 {
+			batchOpts = append(batchOpts, trace.WithMaxExportBatchSize(globalOption.traceBatchSize))
+		}
+		if globalOption.traceBatchTimeout > 0 {
+			batchOpts = append(batchOpts, trace.WithBatchTimeout(globalOption.traceBatchTimeout))
+		}
+		tpOpts = append(tpOpts, trace.WithBatcher(exporter, batchOpts...))
+
+		l.Infow(
+			"msg", "trace exporter enabled",
+			"protocol", globalOption.traceProtocol,
+			"endpoint", globalOption.traceEndpoint,
+			"sample_ratio", globalOption.traceSampleRatio,
+		)
+	}
+
+	tp := trace.NewTracerProvider(tpOpts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+		defer cancel()
+		// Shutdown 会先把缓冲中的 span 刷出再关闭导出器
+		if err := tp.Shutdown(ctx); err != nil {
+			l.Errorw("msg", "shutdown tracer provider failed", "error", err)
+		}
+	}
+
+	return cleanup, nil
+}
+
+// newTraceExporter 根据协议创建 OTLP 导出器
+func newTraceExporter(ctx context.Context) (*otlptrace.Exporter, error) {
+	switch globalOption.traceProtocol {
+	case ProtocolHttp:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(globalOption.traceEndpoint),
+		}
+		if globalOption.traceInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ProtocolGrpc:
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(globalOption.traceEndpoint),
+		}
+		if globalOption.traceInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported otlp protocol: %s", globalOption.traceProtocol)
+	}
+}
+
+// newTraceResource 使用与 NewApp 相同的应用信息构建 Resource
+func newTraceResource(ctx context.Context) (*resource.Resource, error) {
+	info := getAppInfo()
+	return resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(
+			semconv.ServiceName(info.name),
+			semconv.ServiceVersion(info.version),
+			semconv.ServiceInstanceID(info.id),
+			semconv.DeploymentEnvironmentName(info.env),
+		),
+	)
+}
@@ -0,0 +1,94 @@
+package bootstrap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector 进程内的 OTLP HTTP 接收端
+type collector struct {
+	mu    sync.Mutex
+	names []string
+	attrs map[string]string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, kv := range rs.GetResource().GetAttributes() {
+			c.attrs[kv.GetKey()] = kv.GetValue().GetStringValue()
+		}
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				c.names = append(c.names, span.GetName())
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestNewTracerProvider_OtlpHttp(t *testing.T) {
+	c := &collector{attrs: make(map[string]string)}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	t.Setenv("PROJECT_NAME", "demo-service")
+	t.Setenv("PROJECT_SHA", "abc123")
+	t.Setenv("APP_ENV", "staging")
+
+	saved := *globalOption
+	defer func() { *globalOption = saved }()
+	for _, opt := range []Option{
+		WithOtlpHttp(strings.TrimPrefix(srv.URL, "http://")),
+		WithTraceInsecure(),
+		WithTraceSampleRatio(1),
+	} {
+		opt(globalOption)
+	}
+
+	cleanup, err := newTracerProvider(log.DefaultLogger)
+	if err != nil {
+		t.Fatalf("newTracerProvider: %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	// 清理时必须把缓冲中的 span 刷出
+	cleanup()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.names) != 1 || c.names[0] != "test-span" {
+		t.Fatalf("expected exported test-span, got %v", c.names)
+	}
+	want := map[string]string{
+		"service.name":                "demo-service",
+		"service.version":             "abc123",
+		"deployment.environment.name": "staging",
+	}
+	for k, v := range want {
+		if c.attrs[k] != v {
+			t.Errorf("resource attribute %s: expected %q, got %q", k, v, c.attrs[k])
+		}
+	}
+}
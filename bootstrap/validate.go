@@ -0,0 +1,237 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/protobuf/proto"
+)
+
+// FieldViolation 单个配置字段的校验失败信息
+type FieldViolation struct {
+	Path    string // 字段路径，如 data.database.source，为空表示整体校验失败
+	Message string
+}
+
+func (v FieldViolation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// ConfigError 配置校验错误，汇总所有不合法的字段
+type ConfigError struct {
+	Violations []FieldViolation
+}
+
+func (e *ConfigError) Error() string {
+	b := &strings.Builder{}
+	b.WriteString("invalid config:")
+	for _, v := range e.Violations {
+		b.WriteString("\n - ")
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+// protoValidator 延迟创建的 protovalidate 校验器
+var protoValidator = sync.OnceValues(func() (protovalidate.Validator, error) {
+	return protovalidate.New()
+})
+
+// validateConfig 在注入前校验配置，返回汇总了所有字段的 *ConfigError
+//
+// 校验顺序：
+//   - proto 消息使用 protovalidate 规则
+//   - 非 proto 结构体使用 `validate` 标签，支持 required、min=、max=、oneof=
+//   - 实现了 Validate() error 的配置调用该方法
+//
+// validate 标签写错（未知规则、参数不合法）属于代码问题，返回普通错误而不是 *ConfigError。
+func validateConfig(v any) error {
+	var violations []FieldViolation
+
+	if msg, ok := v.(proto.Message); ok {
+		pv, err := protoValidator()
+		if err != nil {
+			return fmt.Errorf("create proto validator: %w", err)
+		}
+		if err := pv.Validate(msg); err != nil {
+			var ve *protovalidate.ValidationError
+			if !errors.As(err, &ve) {
+				return err
+			}
+			for _, item := range ve.Violations {
+				violations = append(violations, FieldViolation{
+					Path:    protovalidate.FieldPathString(item.Proto.GetField()),
+					Message: item.Proto.GetMessage(),
+				})
+			}
+		}
+	} else {
+		out, err := validateTags(reflect.ValueOf(v), "")
+		if err != nil {
+			return err
+		}
+		violations = append(violations, out...)
+	}
+
+	if vv, ok := v.(interface{ Validate() error }); ok {
+		violations = append(violations, methodViolations(vv.Validate())...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ConfigError{Violations: violations}
+}
+
+// methodViolations 把 Validate() 返回的错误展开成字段错误，支持 errors.Join
+func methodViolations(err error) []FieldViolation {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var out []FieldViolation
+		for _, e := range joined.Unwrap() {
+			out = append(out, methodViolations(e)...)
+		}
+		return out
+	}
+	var ce *ConfigError
+	if errors.As(err, &ce) {
+		return ce.Violations
+	}
+	return []FieldViolation{{Message: err.Error()}}
+}
+
+/************************
+ * Struct Tags
+ ************************/
+
+// validateTags 递归校验结构体上的 validate 标签
+func validateTags(rv reflect.Value, prefix string) ([]FieldViolation, error) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var out []FieldViolation
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		path := joinPath(prefix, fieldName(sf))
+
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				msg, err := checkRule(fv, strings.TrimSpace(rule))
+				if err != nil {
+					return nil, fmt.Errorf("validate tag on %s.%s: %w", rt.String(), sf.Name, err)
+				}
+				if msg != "" {
+					out = append(out, FieldViolation{Path: path, Message: msg})
+				}
+			}
+		}
+
+		sub, err := validateTags(fv, path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub...)
+	}
+	return out, nil
+}
+
+// checkRule 校验单条规则，返回错误信息，通过时返回空字符串；规则本身不合法时返回 error
+func checkRule(v reflect.Value, rule string) (string, error) {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "":
+		return "", nil
+	case "required":
+		if v.IsZero() {
+			return "value is required", nil
+		}
+	case "min", "max":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid rule %q", rule)
+		}
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return "", nil
+			}
+			v = v.Elem()
+		}
+		size, ok := measure(v)
+		if !ok {
+			return "", fmt.Errorf("rule %q does not apply to %s", rule, v.Kind())
+		}
+		if name == "min" && size < n {
+			return "must be at least " + arg, nil
+		}
+		if name == "max" && size > n {
+			return "must be at most " + arg, nil
+		}
+	case "oneof":
+		if strings.TrimSpace(arg) == "" {
+			return "", fmt.Errorf("invalid rule %q", rule)
+		}
+		if v.IsZero() {
+			return "", nil
+		}
+		if !slices.Contains(strings.Fields(arg), fmt.Sprint(v.Interface())) {
+			return "must be one of [" + arg + "]", nil
+		}
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule)
+	}
+	return "", nil
+}
+
+// measure 返回数值本身，或字符串、切片、map 的长度
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+// fieldName 优先使用 json 标签作为字段名，与配置文件中的 key 保持一致
+func fieldName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,230 @@
+package bootstrap
+
+import (
+	stderrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type testDatabase struct {
+	Driver  string `json:"driver" validate:"required,oneof=mysql postgres"`
+	Source  string `json:"source" validate:"required"`
+	MaxOpen int    `json:"max_open" validate:"min=1,max=100"`
+}
+
+type testConf struct {
+	Name     string            `json:"name" validate:"required,min=3"`
+	Hosts    []string          `json:"hosts" validate:"max=2"`
+	Timeout  *int              `validate:"min=1"`
+	Database *testDatabase     `json:"database"`
+	Replicas []testDatabase    `json:"replicas"`
+	Labels   map[string]string `json:"-" validate:"max=1"`
+	internal string            `validate:"unknown"`
+}
+
+func (c *testConf) Validate() error {
+	if strings.HasPrefix(c.Name, "_") {
+		return stderrors.Join(
+			stderrors.New("name must not start with _"),
+			&ConfigError{Violations: []FieldViolation{{Path: "name", Message: "reserved"}}},
+		)
+	}
+	return nil
+}
+
+func violationStrings(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var ce *ConfigError
+	if !stderrors.As(err, &ce) {
+		t.Fatalf("expected *ConfigError, got %T: %v", err, err)
+	}
+	out := make([]string, 0, len(ce.Violations))
+	for _, v := range ce.Violations {
+		out = append(out, v.String())
+	}
+	return out
+}
+
+func TestValidateTags(t *testing.T) {
+	zero := 0
+	cases := []struct {
+		name string
+		conf *testConf
+		want []string
+	}{
+		{
+			name: "valid",
+			conf: &testConf{Name: "demo", Database: &testDatabase{Driver: "mysql", Source: "dsn", MaxOpen: 10}},
+		},
+		{
+			name: "nested",
+			conf: &testConf{
+				Name:     "ab",
+				Hosts:    []string{"a", "b", "c"},
+				Timeout:  &zero,
+				Database: &testDatabase{Driver: "sqlite", MaxOpen: 0},
+				Labels:   map[string]string{"a": "1", "b": "2"},
+			},
+			want: []string{
+				"name: must be at least 3",
+				"hosts: must be at most 2",
+				"Timeout: must be at least 1",
+				"database.driver: must be one of [mysql postgres]",
+				"database.source: value is required",
+				"database.max_open: must be at least 1",
+				"Labels: must be at most 1",
+			},
+		},
+		{
+			name: "missing required",
+			conf: &testConf{Database: &testDatabase{Source: "dsn", MaxOpen: 101}},
+			want: []string{
+				"name: value is required",
+				"name: must be at least 3",
+				"database.driver: value is required",
+				"database.max_open: must be at most 100",
+			},
+		},
+		{
+			name: "validate method",
+			conf: &testConf{Name: "_demo"},
+			want: []string{"name must not start with _", "name: reserved"},
+		},
+	}
+	for _, c := range cases {
+		got := violationStrings(t, validateConfig(c.conf))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s:\n got  %q\n want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestValidateTagsInvalidRule(t *testing.T) {
+	cases := []struct {
+		name string
+		conf any
+	}{
+		{"unknown rule", &struct {
+			Name string `validate:"required,email"`
+		}{}},
+		{"invalid arg", &struct {
+			Size int `validate:"min=ten"`
+		}{}},
+		{"empty oneof", &struct {
+			Mode string `validate:"oneof="`
+		}{}},
+		{"unsupported kind", &struct {
+			On bool `validate:"max=1"`
+		}{}},
+		{"nested", &struct {
+			Inner struct {
+				Port int `validate:"gte=1"`
+			}
+		}{}},
+	}
+	for _, c := range cases {
+		err := validateConfig(c.conf)
+		var ce *ConfigError
+		if err == nil || stderrors.As(err, &ce) {
+			t.Errorf("%s: expected a non-config error, got %v", c.name, err)
+		}
+	}
+}
+
+// newProtoConf 构造一个带 protovalidate 规则的动态消息：
+//
+//	message Conf { string name = 1 [min_len = 1]; Database database = 2; }
+//	message Database { string source = 1 [min_len = 1]; int32 max_open = 2 [gte = 1]; }
+func newProtoConf(t *testing.T) (conf, db *dynamicpb.Message) {
+	t.Helper()
+	rules := func(r *validate.FieldRules) *descriptorpb.FieldOptions {
+		opts := &descriptorpb.FieldOptions{}
+		proto.SetExtension(opts, validate.E_Field, r)
+		return opts
+	}
+	minLen := rules(&validate.FieldRules{Type: &validate.FieldRules_String_{String_: &validate.StringRules{MinLen: proto.Uint64(1)}}})
+	gte := rules(&validate.FieldRules{Type: &validate.FieldRules_Int32{Int32: &validate.Int32Rules{
+		GreaterThan: &validate.Int32Rules_Gte{Gte: 1},
+	}}})
+
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, opts *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+			Options:  opts,
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("bootstrap_test/conf.proto"),
+		Package:    proto.String("bootstrap_test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"buf/validate/validate.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Conf"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", minLen),
+					field("database", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".bootstrap_test.Database", nil),
+				},
+			},
+			{
+				Name: proto.String("Database"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("source", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", minLen),
+					field("max_open", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", gte),
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf = dynamicpb.NewMessage(fd.Messages().ByName("Conf"))
+	db = dynamicpb.NewMessage(fd.Messages().ByName("Database"))
+	conf.Set(conf.Descriptor().Fields().ByName("database"), protoreflect.ValueOfMessage(db))
+	return conf, db
+}
+
+func TestValidateProto(t *testing.T) {
+	conf, db := newProtoConf(t)
+
+	got := violationStrings(t, validateConfig(conf))
+	want := []string{
+		"name: value length must be at least 1 characters",
+		"database.source: value length must be at least 1 characters",
+		"database.max_open: value must be greater than or equal to 1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+
+	fields := db.Descriptor().Fields()
+	conf.Set(conf.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString("demo"))
+	db.Set(fields.ByName("source"), protoreflect.ValueOfString("dsn"))
+	db.Set(fields.ByName("max_open"), protoreflect.ValueOfInt32(5))
+	if err := validateConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/mysqlx"
+	"github.com/spf13/cobra"
+)
+
+// dsnEnv 未指定 --dsn 时读取的环境变量
+const dsnEnv = "MYSQL_DSN"
+
+var (
+	dsn     string
+	dir     string
+	table   string
+	pending bool
+)
+
+// engine 迁移命令使用的迁移执行器
+type engine interface {
+	Up(ctx context.Context, steps int) ([]mysqlx.Migration, error)
+	Down(ctx context.Context, steps int) ([]mysqlx.Migration, error)
+	Status(ctx context.Context) ([]mysqlx.MigrationStatus, error)
+	Force(ctx context.Context, version uint64, applied bool) error
+}
+
+// openEngine 连接数据库并创建迁移执行器，测试时替换
+var openEngine = func(source string) (engine, func() error, error) {
+	db, err := sql.Open("mysql", source)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := mysqlx.NewMigrator(db, os.DirFS(dir),
+		mysqlx.WithMigrationTable(table),
+		mysqlx.WithMigrationLogger(log.NewFilter(log.DefaultLogger, log.FilterLevel(log.LevelWarn))),
+	)
+	return m, db.Close, nil
+}
+
+// CmdMigrate manages SQL schema migrations.
+var CmdMigrate = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage SQL schema migrations",
+	Long:  "Apply, revert, inspect and create versioned SQL migrations. Example: ke migrate up --dsn 'user:pass@tcp(127.0.0.1:3306)/demo'",
+}
+
+var cmdUp = &cobra.Command{
+	Use:   "up [n]",
+	Short: "Apply pending migrations, all by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps, err := parseSteps(args, 0)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		return withMigrator(func(m engine) error {
+			done, err := m.Up(context.Background(), steps)
+			for _, mg := range done {
+				_, _ = fmt.Fprintf(out, "✔ Applied %d_%s\n", mg.Version, mg.Name)
+			}
+			if err == nil && len(done) == 0 {
+				_, _ = fmt.Fprintln(out, "No pending migrations")
+			}
+			return err
+		})
+	},
+}
+
+var cmdDown = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Revert applied migrations, one by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps, err := parseSteps(args, 1)
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		return withMigrator(func(m engine) error {
+			done, err := m.Down(context.Background(), steps)
+			for _, mg := range done {
+				_, _ = fmt.Fprintf(out, "✔ Reverted %d_%s\n", mg.Version, mg.Name)
+			}
+			if err == nil && len(done) == 0 {
+				_, _ = fmt.Fprintln(out, "No applied migrations")
+			}
+			return err
+		})
+	},
+}
+
+var cmdStatus = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return withMigrator(func(m engine) error {
+			list, err := m.Status(context.Background())
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+			for _, st := range list {
+				at := "-"
+				if st.Applied {
+					at = st.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, st.Name, statusText(st), at)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var cmdCreate = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new pair of up/down migration files",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		up, down, err := mysqlx.CreateMigration(dir, args[0])
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "✔ Created %s\n", up)
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "✔ Created %s\n", down)
+		return nil
+	},
+}
+
+var cmdForce = &cobra.Command{
+	Use:   "force <version>",
+	Short: "Clear the dirty flag of a version after fixing it by hand",
+	Long:  "Record a version as applied (or as pending with --pending) and clear its dirty flag without running any SQL. Example: ke migrate force 20240101120000",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version: %s", args[0])
+		}
+		return withMigrator(func(m engine) error {
+			if err := m.Force(context.Background(), version, !pending); err != nil {
+				return err
+			}
+			state := "applied"
+			if pending {
+				state = "pending"
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "✔ Forced %d to %s\n", version, state)
+			return nil
+		})
+	},
+}
+
+func init() {
+	flags := CmdMigrate.PersistentFlags()
+	flags.StringVar(&dsn, "dsn", "", "MySQL DSN, defaults to $"+dsnEnv)
+	flags.StringVar(&dir, "dir", "migrations", "migration files directory")
+	flags.StringVar(&table, "table", "schema_migrations", "table that records applied versions")
+
+	cmdForce.Flags().BoolVar(&pending, "pending", false, "record the version as not applied instead of applied")
+
+	CmdMigrate.AddCommand(cmdUp, cmdDown, cmdStatus, cmdCreate, cmdForce)
+}
+
+// withMigrator 连接数据库并执行 fn
+func withMigrator(fn func(m engine) error) error {
+	source := dsn
+	if source == "" {
+		source = os.Getenv(dsnEnv)
+	}
+	if source == "" {
+		return fmt.Errorf("--dsn or $%s is required", dsnEnv)
+	}
+
+	m, closeFn, err := openEngine(source)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	return fn(m)
+}
+
+// parseSteps 解析步数参数，未传时返回 def
+func parseSteps(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid step count: %s", args[0])
+	}
+	return n, nil
+}
+
+// statusText 返回迁移状态的文字描述
+func statusText(st mysqlx.MigrationStatus) string {
+	switch {
+	case st.Missing:
+		return "missing file"
+	case st.Dirty:
+		return "dirty"
+	case st.Modified:
+		return "modified"
+	case st.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
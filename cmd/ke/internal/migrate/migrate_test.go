@@ -0,0 +1,195 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lhlyu/kratos-easy/mysqlx"
+)
+
+// fakeEngine 记录调用的迁移执行器替身
+type fakeEngine struct {
+	status []mysqlx.MigrationStatus
+	forced []string
+	closed bool
+}
+
+func (e *fakeEngine) Up(context.Context, int) ([]mysqlx.Migration, error) {
+	return nil, nil
+}
+
+func (e *fakeEngine) Down(context.Context, int) ([]mysqlx.Migration, error) {
+	return nil, nil
+}
+
+func (e *fakeEngine) Status(context.Context) ([]mysqlx.MigrationStatus, error) {
+	return e.status, nil
+}
+
+func (e *fakeEngine) Force(_ context.Context, version uint64, applied bool) error {
+	e.forced = append(e.forced, fmt.Sprintf("%d %v", version, applied))
+	return nil
+}
+
+// useEngine 把 openEngine 替换为 e，返回打开时收到的 DSN
+func useEngine(t *testing.T, e *fakeEngine) *string {
+	var source string
+	orig := openEngine
+	openEngine = func(s string) (engine, func() error, error) {
+		source = s
+		return e, func() error { e.closed = true; return nil }, nil
+	}
+	t.Cleanup(func() { openEngine = orig })
+	return &source
+}
+
+// run 执行 ke migrate 子命令，返回标准输出
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	dsn, dir, table, pending = "", "migrations", "schema_migrations", false
+
+	out := &bytes.Buffer{}
+	CmdMigrate.SetOut(out)
+	CmdMigrate.SetErr(out)
+	CmdMigrate.SetArgs(args)
+	t.Cleanup(func() {
+		CmdMigrate.SetOut(nil)
+		CmdMigrate.SetErr(nil)
+		CmdMigrate.SetArgs(nil)
+	})
+	_, err := CmdMigrate.ExecuteC()
+	return out.String(), err
+}
+
+func TestCreate(t *testing.T) {
+	tmp := t.TempDir()
+
+	out, err := run(t, "create", "Add Users!", "--dir", tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(tmp, "*"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", files)
+	}
+	name := regexp.MustCompile(`^\d{14}_add_users\.(up|down)\.sql$`)
+	for _, f := range files {
+		if !name.MatchString(filepath.Base(f)) {
+			t.Fatalf("unexpected file name %s", filepath.Base(f))
+		}
+		if !strings.Contains(out, "✔ Created "+f+"\n") {
+			t.Fatalf("output should list %s, got %q", f, out)
+		}
+	}
+
+	if _, err := run(t, "create", "!!!", "--dir", tmp); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+}
+
+func TestCreateExisting(t *testing.T) {
+	tmp := t.TempDir()
+
+	// 版本号精确到秒，提前占用接下来几秒的文件名
+	now := time.Now().UTC()
+	var existing []string
+	for i := range 3 {
+		path := filepath.Join(tmp, now.Add(time.Duration(i)*time.Second).Format("20060102150405")+"_add_users.up.sql")
+		if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		existing = append(existing, path)
+	}
+
+	_, err := run(t, "create", "add_users", "--dir", tmp)
+	if err == nil || !strings.Contains(err.Error(), "file already exists") {
+		t.Fatalf("expected file already exists error, got %v", err)
+	}
+	for _, path := range existing {
+		if data, _ := os.ReadFile(path); string(data) != "keep" {
+			t.Fatalf("%s was overwritten", path)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(tmp, "*.down.sql")); len(files) != 0 {
+		t.Fatalf("no file should be created, got %v", files)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	e := &fakeEngine{status: []mysqlx.MigrationStatus{
+		{Migration: mysqlx.Migration{Version: 1, Name: "users"}, Applied: true, AppliedAt: at},
+		{Migration: mysqlx.Migration{Version: 2, Name: "orders"}, Applied: true, AppliedAt: at, Modified: true},
+		{Migration: mysqlx.Migration{Version: 3, Name: "items"}, Applied: true, AppliedAt: at, Dirty: true},
+		{Migration: mysqlx.Migration{Version: 4, Name: "audits"}, Applied: true, AppliedAt: at, Missing: true},
+		{Migration: mysqlx.Migration{Version: 20240102030405, Name: "add_index"}},
+	}}
+	source := useEngine(t, e)
+
+	out, err := run(t, "status", "--dsn", "root@tcp(127.0.0.1:3306)/demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `VERSION         NAME       STATUS        APPLIED AT
+1               users      applied       2024-01-02 03:04:05
+2               orders     modified      2024-01-02 03:04:05
+3               items      dirty         2024-01-02 03:04:05
+4               audits     missing file  2024-01-02 03:04:05
+20240102030405  add_index  pending       -
+`
+	if out != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+	if *source != "root@tcp(127.0.0.1:3306)/demo" || !e.closed {
+		t.Fatalf("engine opened with %q, closed=%v", *source, e.closed)
+	}
+
+	// 未指定 --dsn 时读取环境变量
+	t.Setenv(dsnEnv, "env@tcp(127.0.0.1:3306)/demo")
+	if _, err := run(t, "status"); err != nil {
+		t.Fatal(err)
+	}
+	if *source != "env@tcp(127.0.0.1:3306)/demo" {
+		t.Fatalf("expected dsn from $%s, got %q", dsnEnv, *source)
+	}
+	t.Setenv(dsnEnv, "")
+	if _, err := run(t, "status"); err == nil || !strings.Contains(err.Error(), dsnEnv) {
+		t.Fatalf("expected missing dsn error, got %v", err)
+	}
+}
+
+func TestForce(t *testing.T) {
+	e := &fakeEngine{}
+	useEngine(t, e)
+
+	out, err := run(t, "force", "3", "--dsn", "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "✔ Forced 3 to applied\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	if out, err = run(t, "force", "4", "--dsn", "demo", "--pending"); err != nil {
+		t.Fatal(err)
+	}
+	if out != "✔ Forced 4 to pending\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+	want := []string{"3 true", "4 false"}
+	if strings.Join(e.forced, "|") != strings.Join(want, "|") {
+		t.Fatalf("forced = %q, want %q", e.forced, want)
+	}
+
+	for _, args := range [][]string{{"force"}, {"force", "v1"}, {"force", "-1"}} {
+		if _, err := run(t, append(args, "--dsn", "demo")...); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
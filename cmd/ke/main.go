@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 
+	"github.com/lhlyu/kratos-easy/cmd/ke/internal/migrate"
 	"github.com/lhlyu/kratos-easy/cmd/ke/internal/proto"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +17,7 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(proto.CmdAPI)
+	rootCmd.AddCommand(migrate.CmdMigrate)
 }
 
 func main() {
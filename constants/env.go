@@ -21,6 +21,8 @@ const (
 	ProjectRef = "PROJECT_REF"
 	// ProjectSha 项目版本
 	ProjectSha = "PROJECT_SHA"
+	// SecretKey 解密配置中 enc: 密文所用的密钥
+	SecretKey = "CONFIG_SECRET_KEY"
 )
 
 // CurrentEnv 获取当前环境
@@ -52,3 +54,14 @@ func IsLocal() bool {
 	}
 	return CurrentEnv() == EnvLocal
 }
+
+// IsDebug 是否允许向客户端输出调试信息，只有本地调试和测试环境返回 true
+//
+// 正式环境和预发布环境即使没有设置 PROJECT_NAME 也返回 false。
+func IsDebug() bool {
+	switch CurrentEnv() {
+	case EnvProduction, EnvStaging:
+		return false
+	}
+	return IsLocal() || IsDevelopment()
+}
@@ -0,0 +1,95 @@
+package healthx
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchInterval Watch 接口重新检查的间隔
+const watchInterval = 5 * time.Second
+
+// GrpcServer grpc.health.v1 的实现
+//
+// service 为空时返回整体就绪状态，否则返回同名检查的状态。
+type GrpcServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	registry *Registry
+}
+
+// NewGrpcServer 基于注册表创建 gRPC 健康检查服务
+func (r *Registry) NewGrpcServer() *GrpcServer {
+	return &GrpcServer{registry: r}
+}
+
+func (s *GrpcServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	st, err := s.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+func (s *GrpcServer) List(ctx context.Context, _ *grpc_health_v1.HealthListRequest) (*grpc_health_v1.HealthListResponse, error) {
+	out := &grpc_health_v1.HealthListResponse{
+		Statuses: make(map[string]*grpc_health_v1.HealthCheckResponse),
+	}
+	for _, name := range append([]string{""}, s.registry.Names()...) {
+		st, err := s.status(ctx, name)
+		if err != nil {
+			continue
+		}
+		out.Statuses[name] = &grpc_health_v1.HealthCheckResponse{Status: st}
+	}
+	return out, nil
+}
+
+func (s *GrpcServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	first := true
+	for {
+		st, err := s.status(ctx, req.GetService())
+		if err != nil {
+			st = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		// 只在状态变化时推送
+		if first || st != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last, first = st, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// status 计算指定服务的状态
+func (s *GrpcServer) status(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	if service == "" {
+		if s.registry.Readiness(ctx).Ready() {
+			return grpc_health_v1.HealthCheckResponse_SERVING, nil
+		}
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+
+	res, ok := s.registry.Check(ctx, service)
+	if !ok {
+		return 0, status.Error(codes.NotFound, "unknown service")
+	}
+	if res.Status != StatusUp || s.registry.ShuttingDown() {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
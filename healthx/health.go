@@ -0,0 +1,205 @@
+package healthx
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查结果状态
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// defaultCheckTimeout 单个检查的默认超时时间
+const defaultCheckTimeout = 3 * time.Second
+
+// Checker 依赖的健康检查函数，返回 nil 表示健康
+type Checker func(ctx context.Context) error
+
+// CheckResult 单个依赖的检查结果
+type CheckResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// Report 整体的检查结果
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready 是否所有检查都通过
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// Registry 健康检查注册表
+type Registry struct {
+	mu           sync.RWMutex
+	checkers     map[string]Checker
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+	details      atomic.Bool // HTTP 响应中是否输出各检查项的名称和错误
+}
+
+// NewRegistry 创建健康检查注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		checkers: make(map[string]Checker),
+		timeout:  defaultCheckTimeout,
+	}
+}
+
+// defaultRegistry 默认的注册表，mysqlx、redisx 创建的客户端会自动注册到这里
+var defaultRegistry = NewRegistry()
+
+// Default 返回默认的注册表
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register 注册一个检查，名称重复时自动追加序号，返回取消注册的函数
+func (r *Registry) Register(name string, c Checker) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := name
+	for i := 2; ; i++ {
+		if _, ok := r.checkers[key]; !ok {
+			break
+		}
+		key = name + "#" + strconv.Itoa(i)
+	}
+	r.checkers[key] = c
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.checkers, key)
+	}
+}
+
+// SetTimeout 设置单个检查的超时时间
+func (r *Registry) SetTimeout(d time.Duration) {
+	if d > 0 {
+		r.mu.Lock()
+		r.timeout = d
+		r.mu.Unlock()
+	}
+}
+
+// SetDetails 设置 HTTP 响应中是否输出各检查项的名称、错误和耗时
+//
+// 默认只输出整体状态，避免在对外的 HTTP 服务上暴露依赖信息。
+func (r *Registry) SetDetails(show bool) {
+	r.details.Store(show)
+}
+
+// Shutdown 标记服务开始关闭，之后就绪检查始终失败，让负载均衡摘除流量
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Resume 取消关闭标记
+func (r *Registry) Resume() {
+	r.shuttingDown.Store(false)
+}
+
+// ShuttingDown 是否正在关闭
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Names 返回已注册的检查名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.checkers))
+}
+
+// Check 执行指定名称的检查，不存在时返回 false
+func (r *Registry) Check(ctx context.Context, name string) (CheckResult, bool) {
+	r.mu.RLock()
+	c, ok := r.checkers[name]
+	timeout := r.timeout
+	r.mu.RUnlock()
+	if !ok {
+		return CheckResult{}, false
+	}
+	return runCheck(ctx, c, timeout), true
+}
+
+// Readiness 并发执行所有检查，服务关闭中或任意检查失败时为 down
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := maps.Clone(r.checkers)
+	timeout := r.timeout
+	r.mu.RUnlock()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := runCheck(ctx, c, timeout)
+			mu.Lock()
+			report.Checks[name] = res
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	if r.ShuttingDown() {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// Liveness 存活检查，只要进程能响应即为 up，不依赖外部服务
+func (r *Registry) Liveness() Report {
+	return Report{Status: StatusUp}
+}
+
+// runCheck 带超时执行单个检查，并把 panic 视为失败
+func runCheck(ctx context.Context, c Checker, timeout time.Duration) (res CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			res = CheckResult{Status: StatusDown, Error: "panic during health check"}
+		}
+		res.Latency = time.Since(start).String()
+	}()
+
+	if err := c(ctx); err != nil {
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusUp}
+}
+
+// Register 注册到默认注册表
+func Register(name string, c Checker) func() {
+	return defaultRegistry.Register(name, c)
+}
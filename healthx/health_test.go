@@ -0,0 +1,163 @@
+package healthx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func serve(t *testing.T, h http.Handler) (int, Report, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, report, w.Body.String()
+}
+
+func TestHandlers(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", func(context.Context) error { return nil })
+	cancel := r.Register("cache", func(context.Context) error { return errors.New("dial tcp 10.0.0.1:6379: refused") })
+
+	// 依赖失败不影响存活检查
+	if code, report, _ := serve(t, r.LivenessHandler()); code != http.StatusOK || report.Status != StatusUp {
+		t.Fatalf("liveness = %d %+v", code, report)
+	}
+
+	// 默认不暴露依赖名称和错误
+	code, report, body := serve(t, r.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown || report.Checks != nil {
+		t.Fatalf("readiness = %d %s", code, body)
+	}
+	if strings.Contains(body, "cache") || strings.Contains(body, "10.0.0.1") {
+		t.Fatalf("details leaked: %s", body)
+	}
+
+	r.SetDetails(true)
+	_, report, _ = serve(t, r.ReadinessHandler())
+	if report.Checks["db"].Status != StatusUp || report.Checks["cache"].Error == "" {
+		t.Fatalf("unexpected details: %+v", report)
+	}
+	r.SetDetails(false)
+
+	cancel()
+	if code, report, _ := serve(t, r.ReadinessHandler()); code != http.StatusOK || report.Status != StatusUp {
+		t.Fatalf("readiness = %d %+v", code, report)
+	}
+
+	// 关闭中就绪检查失败，存活检查不受影响
+	r.Shutdown()
+	if code, _, _ := serve(t, r.ReadinessHandler()); code != http.StatusServiceUnavailable {
+		t.Fatalf("readiness after shutdown = %d", code)
+	}
+	if code, _, _ := serve(t, r.LivenessHandler()); code != http.StatusOK {
+		t.Fatalf("liveness after shutdown = %d", code)
+	}
+	st, _ := r.NewGrpcServer().Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if st.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("grpc status after shutdown = %s", st.GetStatus())
+	}
+
+	r.Resume()
+	if code, _, _ := serve(t, r.ReadinessHandler()); code != http.StatusOK {
+		t.Fatalf("readiness after resume = %d", code)
+	}
+}
+
+func TestReadinessTimeoutAndConcurrency(t *testing.T) {
+	r := NewRegistry()
+	r.SetTimeout(100 * time.Millisecond)
+
+	var running, peak atomic.Int32
+	slow := func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for range 4 {
+		r.Register("slow", slow)
+	}
+	r.Register("hang", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r.Register("panic", func(context.Context) error { panic("boom") })
+
+	start := time.Now()
+	report := r.Readiness(context.Background())
+	elapsed := time.Since(start)
+
+	// 所有检查并发执行，总耗时约等于单项超时
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("readiness took %s", elapsed)
+	}
+	if peak.Load() < 2 {
+		t.Fatalf("checks did not run concurrently, peak %d", peak.Load())
+	}
+	if report.Ready() || len(report.Checks) != 6 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if !strings.Contains(report.Checks["hang"].Error, "deadline") {
+		t.Fatalf("expected timeout error, got %+v", report.Checks["hang"])
+	}
+	if report.Checks["panic"].Status != StatusDown {
+		t.Fatalf("expected panic to be reported as down, got %+v", report.Checks["panic"])
+	}
+	for _, name := range []string{"slow", "slow#2", "slow#3", "slow#4"} {
+		if report.Checks[name].Status != StatusUp {
+			t.Fatalf("%s: %+v", name, report.Checks[name])
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	r := NewRegistry()
+	ok := func(context.Context) error { return nil }
+
+	cancelA := r.Register("redis", ok)
+	cancelB := r.Register("redis", ok)
+	r.Register("redis", ok)
+	if got := strings.Join(r.Names(), ","); got != "redis,redis#2,redis#3" {
+		t.Fatalf("names = %s", got)
+	}
+
+	// 注销只删除自己对应的检查，空出的名称可被复用
+	cancelB()
+	if got := strings.Join(r.Names(), ","); got != "redis,redis#3" {
+		t.Fatalf("names = %s", got)
+	}
+	r.Register("redis", ok)
+	cancelA()
+	if got := strings.Join(r.Names(), ","); got != "redis#2,redis#3" {
+		t.Fatalf("names = %s", got)
+	}
+
+	if _, found := r.Check(context.Background(), "redis"); found {
+		t.Fatal("expected removed check to be unknown")
+	}
+	if _, err := r.NewGrpcServer().Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "redis"}); err == nil {
+		t.Fatal("expected NotFound for removed check")
+	}
+}
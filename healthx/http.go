@@ -0,0 +1,42 @@
+package healthx
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 默认的 HTTP 检查路径
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler 存活检查处理器
+func (r *Registry) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeReport(w, r.Liveness())
+	})
+}
+
+// ReadinessHandler 就绪检查处理器，未就绪时返回 503
+//
+// 未调用 SetDetails(true) 时只输出整体状态。
+func (r *Registry) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Readiness(req.Context())
+		if !r.details.Load() {
+			report.Checks = nil
+		}
+		writeReport(w, report)
+	})
+}
+
+// writeReport 输出检查结果
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
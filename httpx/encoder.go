@@ -6,8 +6,12 @@ import (
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/lhlyu/kratos-easy/constants"
 )
 
+// ReasonPanic 处理请求时发生 panic 的错误原因
+const ReasonPanic = "PANIC"
+
 // EncodeResponse 将 handler 的返回值包装成统一格式并写入 HTTP。
 func EncodeResponse(w http.ResponseWriter, r *http.Request, v any) error {
 	// 支持重定向
@@ -35,9 +39,15 @@ func EncodeError(w http.ResponseWriter, r *http.Request, err error) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(int(se.Code))
 
+	// 本地调试和测试环境输出 panic 的值和堆栈，方便调试
+	var data any
+	if se.Reason == ReasonPanic && len(se.Metadata) > 0 && constants.IsDebug() {
+		data = se.Metadata
+	}
+
 	_ = json.NewEncoder(w).Encode(response{
 		Code: se.Code,
 		Msg:  se.Message,
-		Data: nil,
+		Data: data,
 	})
 }
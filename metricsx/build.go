@@ -0,0 +1,41 @@
+package metricsx
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/lhlyu/kratos-easy/constants"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// buildInfoDesc 构建信息，值固定为 1
+var buildInfoDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(Namespace, "", "build_info"),
+	"Build information from PROJECT_NAME, PROJECT_SHA and PROJECT_REF.",
+	[]string{"service", "version", "ref", "go_version"},
+	nil,
+)
+
+// buildInfoCollector 每次采集时读取环境变量，确保 env 文件加载后的值也能生效
+type buildInfoCollector struct{}
+
+func (buildInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- buildInfoDesc
+}
+
+func (buildInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(buildInfoDesc, prometheus.GaugeValue, 1,
+		getEnvOr(constants.ProjectName, "unknown-service"),
+		getEnvOr(constants.ProjectSha, "unknown-version"),
+		getEnvOr(constants.ProjectRef, "unknown-ref"),
+		runtime.Version(),
+	)
+}
+
+// getEnvOr 获取环境变量，为空时返回默认值
+func getEnvOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,72 @@
+package metricsx
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath 默认的指标路径
+const MetricsPath = "/metrics"
+
+// Namespace 本库输出的指标统一使用的前缀，避免和其他库的同名指标冲突
+const Namespace = "kratos"
+
+// registry 默认的指标注册表，包含 Go 运行时、进程和构建信息指标
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		buildInfoCollector{},
+		requestsTotal,
+		requestDuration,
+	)
+}
+
+// Registry 返回默认的指标注册表，可用于注册自定义指标
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// Handler 返回输出默认注册表中所有指标的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		Registry: registry,
+	})
+}
+
+// names 已注册的连接池名称，用于重名时追加序号
+var names = struct {
+	mu   sync.Mutex
+	used map[string]bool
+}{used: make(map[string]bool)}
+
+// register 按名称注册一个采集器，名称重复时自动追加序号，返回取消注册的函数
+func register(name string, newCollector func(name string) prometheus.Collector) func() {
+	names.mu.Lock()
+	defer names.mu.Unlock()
+
+	key := name
+	for i := 2; names.used[key]; i++ {
+		key = name + "#" + strconv.Itoa(i)
+	}
+
+	c := newCollector(key)
+	if err := registry.Register(c); err != nil {
+		return func() {}
+	}
+	names.used[key] = true
+
+	return func() {
+		names.mu.Lock()
+		defer names.mu.Unlock()
+		registry.Unregister(c)
+		delete(names.used, key)
+	}
+}
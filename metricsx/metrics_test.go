@@ -0,0 +1,77 @@
+package metricsx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type testTransport struct {
+	transport.Transporter
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind { return transport.KindGRPC }
+func (t *testTransport) Operation() string    { return t.operation }
+
+func TestServerLabels(t *testing.T) {
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: "/api.v1.User/Get"})
+	m := Server()
+
+	ok := m(func(context.Context, any) (any, error) { return "ok", nil })
+	fail := m(func(context.Context, any) (any, error) {
+		return nil, errors.NotFound("USER_NOT_FOUND", "用户不存在")
+	})
+	for range 2 {
+		_, _ = ok(ctx, nil)
+	}
+	_, _ = fail(ctx, nil)
+
+	if n := testutil.ToFloat64(requestsTotal.WithLabelValues("server", "grpc", "/api.v1.User/Get", "200", "")); n != 2 {
+		t.Fatalf("ok requests = %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(requestsTotal.WithLabelValues("server", "grpc", "/api.v1.User/Get", "404", "USER_NOT_FOUND")); n != 1 {
+		t.Fatalf("failed requests = %v, want 1", n)
+	}
+	if n := testutil.CollectAndCount(requestDuration, "kratos_request_duration_seconds"); n != 1 {
+		t.Fatalf("duration series = %d, want 1", n)
+	}
+}
+
+func TestRegisterDB(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pw@tcp(127.0.0.1:3306)/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	count := func() int {
+		n, err := testutil.GatherAndCount(registry, "go_sql_open_connections")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	unregister := RegisterDB("app", db)
+	unregister2 := RegisterDB("app", db)
+	if n := count(); n != 2 {
+		t.Fatalf("open_connections series = %d, want 2", n)
+	}
+	unregister()
+	unregister2()
+	if n := count(); n != 0 {
+		t.Fatalf("open_connections series = %d after unregister, want 0", n)
+	}
+
+	// 取消注册后名称可以复用
+	defer RegisterDB("app", db)()
+	if n := count(); n != 1 || names.used["app#2"] {
+		t.Fatalf("unexpected registration: %d %v", n, names.used)
+	}
+}
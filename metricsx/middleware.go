@@ -0,0 +1,67 @@
+package metricsx
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestsTotal 请求总数
+var requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "requests_total",
+	Help:      "Total number of requests by kind, transport, operation, code and reason.",
+}, []string{"kind", "transport", "operation", "code", "reason"})
+
+// requestDuration 请求耗时
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Name:      "request_duration_seconds",
+	Help:      "Request latency in seconds by kind, transport and operation.",
+	Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"kind", "transport", "operation"})
+
+// Server 返回服务端指标中间件
+func Server() middleware.Middleware {
+	return metricsMiddleware("server", transport.FromServerContext)
+}
+
+// Client 返回客户端指标中间件
+func Client() middleware.Middleware {
+	return metricsMiddleware("client", transport.FromClientContext)
+}
+
+// metricsMiddleware 记录请求数和耗时，operation 与 logging 中间件取值一致
+func metricsMiddleware(
+	kind string,
+	fromContext func(ctx context.Context) (transport.Transporter, bool),
+) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			start := time.Now()
+
+			var transportType, operation string
+			if info, ok := fromContext(ctx); ok {
+				transportType = info.Kind().String()
+				operation = info.Operation()
+			}
+
+			reply, err := handler(ctx, req)
+
+			code, reason := 200, ""
+			if se := errors.FromError(err); se != nil {
+				code, reason = int(se.Code), se.Reason
+			}
+
+			requestsTotal.WithLabelValues(kind, transportType, operation, strconv.Itoa(code), reason).Inc()
+			requestDuration.WithLabelValues(kind, transportType, operation).Observe(time.Since(start).Seconds())
+
+			return reply, err
+		}
+	}
+}
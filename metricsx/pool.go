@@ -0,0 +1,83 @@
+package metricsx
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+)
+
+// RegisterDB 注册 database/sql 连接池指标，name 作为 db_name 标签，返回取消注册的函数
+func RegisterDB(name string, db *sql.DB) func() {
+	return register(name, func(name string) prometheus.Collector {
+		return collectors.NewDBStatsCollector(db, name)
+	})
+}
+
+// RedisPoolStater 可以获取连接池状态的 redis 客户端，如 *redis.Client、*redis.ClusterClient
+type RedisPoolStater interface {
+	PoolStats() *redis.PoolStats
+}
+
+// RegisterRedis 注册 go-redis 连接池指标，name 作为 redis_name 标签，返回取消注册的函数
+func RegisterRedis(name string, client RedisPoolStater) func() {
+	return register(name, func(name string) prometheus.Collector {
+		return newRedisPoolCollector(client, name)
+	})
+}
+
+// redisPoolCollector go-redis 连接池指标采集器
+type redisPoolCollector struct {
+	client RedisPoolStater
+
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	timeouts     *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	totalConns   *prometheus.Desc
+	idleConns    *prometheus.Desc
+	staleConns   *prometheus.Desc
+}
+
+func newRedisPoolCollector(client RedisPoolStater, name string) *redisPoolCollector {
+	labels := prometheus.Labels{"redis_name": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("go_redis_pool_"+metric, help, nil, labels)
+	}
+	return &redisPoolCollector{
+		client:       client,
+		hits:         desc("hits_total", "The number of times a free connection was found in the pool."),
+		misses:       desc("misses_total", "The number of times a free connection was not found in the pool."),
+		timeouts:     desc("timeouts_total", "The number of times a wait timeout occurred."),
+		waitCount:    desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		totalConns:   desc("total_connections", "The number of total connections in the pool."),
+		idleConns:    desc("idle_connections", "The number of idle connections in the pool."),
+		staleConns:   desc("stale_connections_total", "The number of stale connections removed from the pool."),
+	}
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(s.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, float64(s.WaitDurationNs)/1e9)
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(s.StaleConns))
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// defaultAPIKeyHeader 默认读取 API key 的请求头
+const defaultAPIKeyHeader = "X-API-Key"
+
+// ErrInvalidAPIKey API key 不存在或已失效
+var ErrInvalidAPIKey = errors.New("auth: invalid api key")
+
+// KeyStore API key 存储，key 不存在时返回 nil, nil
+//
+// 返回的错误视为存储不可用，中间件返回 503 而不是 401。
+type KeyStore interface {
+	Lookup(ctx context.Context, key string) (*Claims, error)
+}
+
+// KeyStoreFunc 函数形式的 KeyStore
+type KeyStoreFunc func(ctx context.Context, key string) (*Claims, error)
+
+// Lookup 实现 KeyStore
+func (f KeyStoreFunc) Lookup(ctx context.Context, key string) (*Claims, error) {
+	return f(ctx, key)
+}
+
+// StaticKeys 固定的 API key 列表，适合内部服务之间调用
+type StaticKeys map[string]*Claims
+
+// Lookup 实现 KeyStore
+func (s StaticKeys) Lookup(_ context.Context, key string) (*Claims, error) {
+	return s[key], nil
+}
+
+// APIKey 基于 API key 的认证
+type APIKey struct {
+	store  KeyStore
+	header string
+}
+
+// APIKeyOption API key 认证配置项函数
+type APIKeyOption func(*APIKey)
+
+// WithAPIKeyHeader 设置读取 API key 的请求头，默认 X-API-Key
+func WithAPIKeyHeader(name string) APIKeyOption {
+	return func(a *APIKey) {
+		if name != "" {
+			a.header = name
+		}
+	}
+}
+
+// NewAPIKey 创建 API key 认证，通过 store 查询 key 对应的身份信息
+func NewAPIKey(store KeyStore, opts ...APIKeyOption) *APIKey {
+	a := &APIKey{
+		store:  store,
+		header: defaultAPIKeyHeader,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Authenticate 实现 Provider
+func (a *APIKey) Authenticate(ctx context.Context, tr transport.Transporter) (*Claims, error) {
+	key := tr.RequestHeader().Get(a.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	c, err := a.store.Lookup(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	if c == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// 复制一份，避免修改 store 中的数据
+	claims := *c
+	claims.Method = MethodAPIKey
+	return &claims, nil
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// 认证失败时返回的错误原因
+const (
+	ReasonUnauthorized = "UNAUTHORIZED"
+	ReasonForbidden    = "FORBIDDEN"
+	ReasonUnavailable  = "AUTH_UNAVAILABLE"
+)
+
+var (
+	// ErrNoCredentials 请求中没有该 Provider 支持的凭证
+	ErrNoCredentials = stderrors.New("auth: no credentials")
+	// ErrUnavailable 凭证存储或公钥服务不可用，无法判断凭证是否有效
+	//
+	// 自定义 KeyStore、Provider 遇到基础设施故障时应包装该错误返回，中间件会返回 503 而不是 401。
+	ErrUnavailable = stderrors.New("auth: credential backend unavailable")
+)
+
+// Provider 认证方式
+type Provider interface {
+	// Authenticate 从请求中读取凭证并校验，请求中没有该类凭证时返回 ErrNoCredentials
+	Authenticate(ctx context.Context, tr transport.Transporter) (*Claims, error)
+}
+
+/************************
+ * Option & Config
+ ************************/
+
+// options 定义认证中间件的配置项
+type options struct {
+	providers []Provider
+	public    []string
+}
+
+// Option 定义配置函数
+type Option func(*options)
+
+// newOptions 初始化配置
+func newOptions(opts ...Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithProvider 添加认证方式，按添加顺序使用第一个携带了凭证的方式
+func WithProvider(providers ...Provider) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, providers...)
+	}
+}
+
+// WithAllowList 设置无需认证的接口，支持 * 通配符
+//
+// 这些接口携带了有效凭证时仍会写入身份信息，凭证无效时按匿名处理。
+func WithAllowList(operations ...string) Option {
+	return func(o *options) {
+		o.public = append(o.public, operations...)
+	}
+}
+
+/************************
+ * Middleware
+ ************************/
+
+// Server 返回认证中间件，认证通过后身份信息写入 context，通过 FromContext 读取
+//
+// 没有凭证或凭证无效时返回 401，凭证不允许调用当前接口时返回 403，
+// 凭证存储不可用（ErrUnavailable）时返回 503，白名单接口同样如此，不会降级为匿名。
+//
+// logger 用于记录认证失败的原因，原因不会返回给客户端。
+func Server(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts...)
+	helper := log.NewHelper(logger)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			operation := tr.Operation()
+			public := o.isPublic(operation)
+
+			claims, err := o.authenticate(ctx, tr)
+			if errors.Is(err, ErrUnavailable) {
+				helper.WithContext(ctx).Errorw("msg", "authentication unavailable", "operation", operation, "error", err)
+				return nil, errors.ServiceUnavailable(ReasonUnavailable, "认证服务暂不可用").WithCause(err)
+			}
+			if err != nil {
+				if public {
+					return handler(ctx, req)
+				}
+				helper.WithContext(ctx).Infow("msg", "authentication failed", "operation", operation, "error", err)
+				return nil, errors.Unauthorized(ReasonUnauthorized, "未登录或登录已过期").WithCause(err)
+			}
+
+			if !public && !claims.allows(operation) {
+				helper.WithContext(ctx).Infow("msg", "operation not allowed", "operation", operation, "subject", claims.Subject)
+				return nil, errors.Forbidden(ReasonForbidden, "无权访问")
+			}
+			return handler(NewContext(ctx, claims), req)
+		}
+	}
+}
+
+// authenticate 使用第一个携带了凭证的认证方式
+func (o *options) authenticate(ctx context.Context, tr transport.Transporter) (*Claims, error) {
+	for _, p := range o.providers {
+		claims, err := p.Authenticate(ctx, tr)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return claims, err
+	}
+	return nil, ErrNoCredentials
+}
+
+// isPublic 接口是否无需认证
+func (o *options) isPublic(operation string) bool {
+	for _, pattern := range o.public {
+		if MatchOperation(pattern, operation) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"math/big"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type headerCarrier nethttp.Header
+
+func (h headerCarrier) Get(key string) string      { return nethttp.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { nethttp.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { nethttp.Header(h).Add(key, value) }
+func (h headerCarrier) Values(key string) []string { return nethttp.Header(h).Values(key) }
+func (h headerCarrier) Keys() []string             { return nil }
+
+type testTransport struct {
+	operation string
+	req       headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return t.operation }
+func (t *testTransport) RequestHeader() transport.Header { return t.req }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+// call 调用中间件，返回 handler 看到的身份信息
+func call(m func(context.Context, any) (any, error), operation string, headers ...string) (*Claims, error) {
+	tr := &testTransport{operation: operation, req: headerCarrier{}}
+	for i := 0; i+1 < len(headers); i += 2 {
+		tr.req.Set(headers[i], headers[i+1])
+	}
+	reply, err := m(transport.NewServerContext(context.Background(), tr), nil)
+	if err != nil {
+		return nil, err
+	}
+	c, _ := reply.(*Claims)
+	return c, nil
+}
+
+func echoClaims(ctx context.Context, _ any) (any, error) {
+	c, _ := FromContext(ctx)
+	return c, nil
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + s
+}
+
+func TestServerJWTAndAPIKey(t *testing.T) {
+	secret := []byte("secret")
+	j, err := NewJWT(WithSecret(secret), WithIssuer("kratos-easy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := StaticKeys{
+		"k-report": {Subject: "report-job", Operations: []string{"/api.v1.Report/*"}},
+	}
+	m := Server(log.NewStdLogger(io.Discard),
+		WithProvider(j, NewAPIKey(keys)),
+		WithAllowList("/api.v1.Public/*"),
+	)(echoClaims)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := sign(t, jwt.SigningMethodHS256, "", secret, jwt.MapClaims{
+		"sub": "u1", "iss": "kratos-easy", "exp": exp, "roles": []string{"admin"}, "scope": "read write",
+	})
+	c, err := call(m, "/api.v1.User/Get", "Authorization", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "u1" || c.Method != MethodJWT || !c.HasRole("admin") || !c.HasScope("write") {
+		t.Fatalf("unexpected claims: %+v", c)
+	}
+
+	unauthorized := map[string][]string{
+		"missing":       {},
+		"wrong issuer":  {"Authorization", sign(t, jwt.SigningMethodHS256, "", secret, jwt.MapClaims{"sub": "u1", "iss": "other", "exp": exp})},
+		"expired":       {"Authorization", sign(t, jwt.SigningMethodHS256, "", secret, jwt.MapClaims{"sub": "u1", "iss": "kratos-easy", "exp": time.Now().Add(-time.Minute).Unix()})},
+		"no exp":        {"Authorization", sign(t, jwt.SigningMethodHS256, "", secret, jwt.MapClaims{"sub": "u1", "iss": "kratos-easy"})},
+		"bad signature": {"Authorization", sign(t, jwt.SigningMethodHS256, "", []byte("other"), jwt.MapClaims{"sub": "u1", "iss": "kratos-easy", "exp": exp})},
+		"unknown key":   {"X-API-Key", "nope"},
+	}
+	for name, headers := range unauthorized {
+		if _, err := call(m, "/api.v1.User/Get", headers...); !errors.IsUnauthorized(err) {
+			t.Fatalf("%s: expected 401, got %v", name, err)
+		}
+	}
+
+	// API key 只允许调用 Report 服务
+	if c, err := call(m, "/api.v1.Report/Export", "X-API-Key", "k-report"); err != nil || c.Method != MethodAPIKey {
+		t.Fatalf("expected api key to be accepted, got %+v, %v", c, err)
+	}
+	if _, err := call(m, "/api.v1.User/Get", "X-API-Key", "k-report"); !errors.IsForbidden(err) {
+		t.Fatalf("expected 403, got %v", err)
+	}
+
+	// 无需认证的接口：无效凭证按匿名处理，有效凭证仍写入身份信息
+	if c, err := call(m, "/api.v1.Public/Ping", "X-API-Key", "nope"); err != nil || c != nil {
+		t.Fatalf("expected anonymous access, got %+v, %v", c, err)
+	}
+	if c, err := call(m, "/api.v1.Public/Ping", "Authorization", token); err != nil || c.Subject != "u1" {
+		t.Fatalf("expected claims on public operation, got %+v, %v", c, err)
+	}
+}
+
+func TestServerStoreUnavailable(t *testing.T) {
+	store := KeyStoreFunc(func(context.Context, string) (*Claims, error) {
+		return nil, stderrors.New("redis: connection refused")
+	})
+	m := Server(log.NewStdLogger(io.Discard),
+		WithProvider(NewAPIKey(store)),
+		WithAllowList("/api.v1.Public/*"),
+	)(echoClaims)
+
+	// 存储故障返回 503，白名单接口也不降级为匿名
+	for _, operation := range []string{"/api.v1.User/Get", "/api.v1.Public/Ping"} {
+		_, err := call(m, operation, "X-API-Key", "k1")
+		if se := errors.FromError(err); se.Code != 503 || se.Reason != ReasonUnavailable {
+			t.Fatalf("%s: expected 503, got %v", operation, err)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	var (
+		fetches atomic.Int32
+		rotated atomic.Bool
+	)
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		fetches.Add(1)
+		keys := []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		}
+		if rotated.Load() {
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	j, err := NewJWT(WithJWKSURL(srv.URL, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Server(log.NewStdLogger(io.Discard), WithProvider(j))(echoClaims)
+	claims := jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for range 3 {
+		if _, err := call(m, "/op", "Authorization", sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", n)
+	}
+
+	// HS256 使用公钥作为密钥的 alg 混淆攻击必须被拒绝
+	forged := sign(t, jwt.SigningMethodHS256, "rsa-1", rsaKey.N.Bytes(), claims)
+	if _, err := call(m, "/op", "Authorization", forged); !errors.IsUnauthorized(err) {
+		t.Fatalf("expected 401 for alg confusion, got %v", err)
+	}
+
+	// 未知 kid 在最小间隔内不会重新加载
+	rotated.Store(true)
+	ecToken := sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims)
+	if _, err := call(m, "/op", "Authorization", ecToken); !errors.IsUnauthorized(err) {
+		t.Fatalf("expected 401 before refresh, got %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", n)
+	}
+
+	// 超过最小间隔后重新加载，得到轮换后的公钥
+	j.jwks.mu.Lock()
+	j.jwks.loadedAt = time.Now().Add(-2 * minJWKSRefresh)
+	j.jwks.mu.Unlock()
+	if _, err := call(m, "/op", "Authorization", ecToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// JWKS 服务不可用时返回 503，已缓存的公钥继续可用
+	srv.Close()
+	j.jwks.mu.Lock()
+	j.jwks.loadedAt = time.Now().Add(-2 * minJWKSRefresh)
+	j.jwks.mu.Unlock()
+	_, err = call(m, "/op", "Authorization", sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, claims))
+	if se := errors.FromError(err); se.Code != 503 {
+		t.Fatalf("expected 503 when jwks is down, got %v", err)
+	}
+	if _, err := call(m, "/op", "Authorization", ecToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatchOperation(t *testing.T) {
+	cases := []struct {
+		pattern, operation string
+		want               bool
+	}{
+		{"*", "/api.v1.User/Get", true},
+		{"/api.v1.User/Get", "/api.v1.User/Get", true},
+		{"/api.v1.User/Get", "/api.v1.User/GetAll", false},
+		{"/api.v1.User/*", "/api.v1.User/Get", true},
+		{"/api.v1.User/*", "/api.v1.Order/Get", false},
+		{"/api.*/Get", "/api.v1.User/Get", true},
+		{"/api.*/Get", "/api.v1.User/List", false},
+		{"*/Delete*", "/api.v1.User/DeleteAll", true},
+	}
+	for _, c := range cases {
+		if got := MatchOperation(c.pattern, c.operation); got != c.want {
+			t.Errorf("MatchOperation(%q, %q) = %v, want %v", c.pattern, c.operation, got, c.want)
+		}
+	}
+}
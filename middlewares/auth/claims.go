@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 认证方式
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+)
+
+// Claims 认证通过后的身份信息
+type Claims struct {
+	// Subject 认证主体，JWT 的 sub 或 API key 的所有者
+	Subject string
+	// Issuer 签发方
+	Issuer string
+	// Audience 接收方
+	Audience []string
+	// ExpiresAt 过期时间，零值表示不过期
+	ExpiresAt time.Time
+	// Roles 角色
+	Roles []string
+	// Scopes 授权范围
+	Scopes []string
+	// Operations 凭证允许调用的接口，支持 * 通配符，为空表示不限制
+	Operations []string
+	// Method 认证方式，MethodJWT 或 MethodAPIKey
+	Method string
+	// Extra 其他属性，JWT 为全部原始 claims
+	Extra map[string]any
+}
+
+// HasRole 是否拥有角色
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope 是否拥有授权范围
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// allows 凭证是否允许调用接口
+func (c *Claims) allows(operation string) bool {
+	if len(c.Operations) == 0 {
+		return true
+	}
+	for _, pattern := range c.Operations {
+		if MatchOperation(pattern, operation) {
+			return true
+		}
+	}
+	return false
+}
+
+type claimsKey struct{}
+
+// NewContext 把身份信息写入 context
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// FromContext 从 context 读取身份信息
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok && c != nil
+}
+
+// Subject 返回认证主体，未认证时返回空字符串
+//
+// 可以直接用于 ratelimit.BySubject。
+func Subject(ctx context.Context) string {
+	if c, ok := FromContext(ctx); ok {
+		return c.Subject
+	}
+	return ""
+}
+
+// MatchOperation 判断接口是否匹配，pattern 中的 * 匹配任意字符
+//
+//	/api.v1.User/Get  精确匹配
+//	/api.v1.User/*    匹配 User 服务的所有接口
+//	*                 匹配所有接口
+func MatchOperation(pattern, operation string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == operation
+	}
+	if !strings.HasPrefix(operation, pattern[:star]) {
+		return false
+	}
+	operation, pattern = operation[star:], pattern[star+1:]
+	if pattern == "" {
+		return true
+	}
+
+	// 尝试 * 匹配的每一种长度
+	for i := range len(operation) + 1 {
+		if MatchOperation(pattern, operation[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minJWKSRefresh 遇到未知 kid 时重新加载的最小间隔，避免伪造的 kid 打爆 JWKS 服务
+const minJWKSRefresh = time.Minute
+
+// ErrUnknownKey 找不到 kid 对应的公钥
+var ErrUnknownKey = errors.New("auth: unknown signing key")
+
+// jwks 带缓存的 JWKS 公钥集合
+//
+// 缓存超过 refresh 后重新加载，遇到未知 kid 时也会重新加载，加载失败时继续使用旧的公钥。
+type jwks struct {
+	load    func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+	reload   sync.Mutex
+}
+
+// newJWKSFile 从本地文件加载 JWKS
+func newJWKSFile(path string, refresh time.Duration) *jwks {
+	return &jwks{
+		load: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+		refresh: refresh,
+	}
+}
+
+// newJWKSURL 从 URL 加载 JWKS
+func newJWKSURL(url string, refresh time.Duration) *jwks {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &jwks{
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("auth: fetch jwks %s: %s", url, resp.Status)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+		refresh: refresh,
+	}
+}
+
+// key 返回 kid 对应的公钥，kid 为空且只有一个公钥时返回该公钥
+func (k *jwks) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.RLock()
+	key, ok := k.lookup(kid)
+	loadedAt := k.loadedAt
+	k.mu.RUnlock()
+
+	age := time.Since(loadedAt)
+	if ok && age < k.refresh {
+		return key, nil
+	}
+	if !ok && !loadedAt.IsZero() && age < minJWKSRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	if err := k.reloadIfBefore(ctx, loadedAt); err != nil && !ok {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// lookup 查找公钥，调用方需要持有读锁
+func (k *jwks) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// reloadIfBefore 重新加载公钥，其他协程已经在 loadedAt 之后加载过时直接返回
+func (k *jwks) reloadIfBefore(ctx context.Context, loadedAt time.Time) error {
+	k.reload.Lock()
+	defer k.reload.Unlock()
+
+	k.mu.RLock()
+	done := k.loadedAt.After(loadedAt)
+	k.mu.RUnlock()
+	if done {
+		return nil
+	}
+
+	data, err := k.load(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys, k.loadedAt = keys, time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+// jwk JSON Web Key 中用到的字段
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS 解析 JWKS，只保留用于签名的 RSA、EC、Ed25519 公钥
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse jwk %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey 把 JWK 转成公钥，不支持的类型返回 nil
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBase64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// 未压缩格式：0x04 || X || Y，坐标按曲线长度左侧补零
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("invalid ec point")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(x):], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBase64(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeBase64 解码 base64url，兼容带填充的写法
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultJWKSRefresh JWKS 默认缓存时间
+const defaultJWKSRefresh = time.Hour
+
+// bearerPrefix Authorization 请求头中 token 的前缀
+const bearerPrefix = "Bearer "
+
+// jwtOptions JWT 认证配置
+type jwtOptions struct {
+	secret      []byte
+	keys        map[string]crypto.PublicKey
+	jwksFile    string
+	jwksURL     string
+	jwksRefresh time.Duration
+	issuer      string
+	audience    string
+	leeway      time.Duration
+	rolesClaim  string
+}
+
+// JWTOption JWT 认证配置项函数
+type JWTOption func(*jwtOptions)
+
+// WithSecret 设置 HS256/HS384/HS512 的密钥
+func WithSecret(secret []byte) JWTOption {
+	return func(o *jwtOptions) {
+		o.secret = secret
+	}
+}
+
+// WithPublicKey 添加 RS/PS/ES/EdDSA 的公钥，kid 为空时匹配没有 kid 的 token
+func WithPublicKey(kid string, key crypto.PublicKey) JWTOption {
+	return func(o *jwtOptions) {
+		o.keys[kid] = key
+	}
+}
+
+// WithJWKSFile 从本地文件加载 JWKS，文件更新后在 refresh 或遇到未知 kid 时重新加载
+func WithJWKSFile(path string, refresh time.Duration) JWTOption {
+	return func(o *jwtOptions) {
+		o.jwksFile = path
+		o.jwksRefresh = refresh
+	}
+}
+
+// WithJWKSURL 从 URL 加载 JWKS，缓存 refresh 时间，默认 1 小时
+func WithJWKSURL(url string, refresh time.Duration) JWTOption {
+	return func(o *jwtOptions) {
+		o.jwksURL = url
+		o.jwksRefresh = refresh
+	}
+}
+
+// WithIssuer 校验签发方 iss
+func WithIssuer(issuer string) JWTOption {
+	return func(o *jwtOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithAudience 校验接收方 aud
+func WithAudience(audience string) JWTOption {
+	return func(o *jwtOptions) {
+		o.audience = audience
+	}
+}
+
+// WithLeeway 设置校验 exp、nbf、iat 时允许的时钟偏差
+func WithLeeway(d time.Duration) JWTOption {
+	return func(o *jwtOptions) {
+		o.leeway = d
+	}
+}
+
+// WithRolesClaim 设置角色所在的 claim，默认 roles
+func WithRolesClaim(name string) JWTOption {
+	return func(o *jwtOptions) {
+		if name != "" {
+			o.rolesClaim = name
+		}
+	}
+}
+
+// JWT 基于 JWT 的认证，从 Authorization: Bearer <token> 读取 token
+type JWT struct {
+	opts   *jwtOptions
+	jwks   *jwks
+	parser *jwt.Parser
+}
+
+// NewJWT 创建 JWT 认证，至少需要配置密钥、公钥或 JWKS 中的一种
+//
+// 使用本地 JWKS 文件时会立即加载一次，URL 在第一次请求时加载。
+func NewJWT(opts ...JWTOption) (*JWT, error) {
+	o := &jwtOptions{
+		keys:       make(map[string]crypto.PublicKey),
+		rolesClaim: "roles",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.jwksRefresh <= 0 {
+		o.jwksRefresh = defaultJWKSRefresh
+	}
+
+	j := &JWT{opts: o}
+	switch {
+	case o.jwksFile != "":
+		j.jwks = newJWKSFile(o.jwksFile, o.jwksRefresh)
+		if err := j.jwks.reloadIfBefore(context.Background(), time.Time{}); err != nil {
+			return nil, err
+		}
+	case o.jwksURL != "":
+		j.jwks = newJWKSURL(o.jwksURL, o.jwksRefresh)
+	}
+
+	// 只接受已配置密钥对应的算法，防止 alg 混淆攻击
+	var methods []string
+	if len(o.secret) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if len(o.keys) > 0 || j.jwks != nil {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA")
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("auth: jwt requires a secret, public key or jwks")
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithLeeway(o.leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if o.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(o.issuer))
+	}
+	if o.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(o.audience))
+	}
+	j.parser = jwt.NewParser(parserOpts...)
+	return j, nil
+}
+
+// Authenticate 实现 Provider
+func (j *JWT) Authenticate(ctx context.Context, tr transport.Transporter) (*Claims, error) {
+	header := tr.RequestHeader().Get("Authorization")
+	if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return nil, ErrNoCredentials
+	}
+	return j.Verify(ctx, strings.TrimSpace(header[len(bearerPrefix):]))
+}
+
+// Verify 校验 token 并返回身份信息
+func (j *JWT) Verify(ctx context.Context, token string) (*Claims, error) {
+	mc := jwt.MapClaims{}
+	if _, err := j.parser.ParseWithClaims(token, mc, func(t *jwt.Token) (any, error) {
+		return j.key(ctx, t)
+	}); err != nil {
+		return nil, err
+	}
+
+	c := &Claims{
+		Method: MethodJWT,
+		Roles:  stringList(mc[j.opts.rolesClaim]),
+		Scopes: strings.Fields(stringValue(mc["scope"])),
+		Extra:  mc,
+	}
+	c.Subject, _ = mc.GetSubject()
+	c.Issuer, _ = mc.GetIssuer()
+	c.Audience, _ = mc.GetAudience()
+	if exp, _ := mc.GetExpirationTime(); exp != nil {
+		c.ExpiresAt = exp.Time
+	}
+	return c, nil
+}
+
+// key 根据 token 的算法和 kid 选择校验密钥
+func (j *JWT) key(ctx context.Context, t *jwt.Token) (any, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		return j.opts.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := j.opts.keys[kid]
+	if !ok {
+		if j.jwks == nil {
+			return nil, ErrUnknownKey
+		}
+		var err error
+		if key, err = j.jwks.key(ctx, kid); err != nil {
+			return nil, err
+		}
+	}
+
+	// 公钥类型必须和算法一致
+	switch t.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		_, ok = key.(*ecdsa.PublicKey)
+	case *jwt.SigningMethodEd25519:
+		_, ok = key.(ed25519.PublicKey)
+	default:
+		ok = false
+	}
+	if !ok {
+		return nil, fmt.Errorf("auth: key %q does not match alg %s", kid, t.Method.Alg())
+	}
+	return key, nil
+}
+
+// stringList 读取字符串数组或空格分隔的字符串
+func stringList(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return strings.Fields(t)
+	case []any:
+		list := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// stringValue 读取字符串
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return s
+}
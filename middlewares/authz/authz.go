@@ -0,0 +1,188 @@
+package authz
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/lhlyu/kratos-easy/bootstrap"
+	"github.com/lhlyu/kratos-easy/middlewares/auth"
+)
+
+// 授权失败时返回的错误原因
+const (
+	ReasonUnauthorized = auth.ReasonUnauthorized
+	ReasonForbidden    = auth.ReasonForbidden
+)
+
+// Decision 授权决策
+type Decision struct {
+	// Allowed 是否允许
+	Allowed bool
+	// Rule 决定结果的规则名，没有规则匹配时为 default
+	Rule string
+}
+
+/************************
+ * Option & Config
+ ************************/
+
+// options 定义授权的配置项
+type options struct {
+	conditions map[string]Condition
+	auditAllow bool
+}
+
+// Option 定义配置函数
+type Option func(*options)
+
+// WithCondition 注册自定义条件，规则通过 conditions 引用
+func WithCondition(name string, fn Condition) Option {
+	return func(o *options) {
+		if name != "" && fn != nil {
+			o.conditions[name] = fn
+		}
+	}
+}
+
+// WithAuditDenyOnly 审计日志只记录拒绝的决策，默认全部记录
+func WithAuditDenyOnly() Option {
+	return func(o *options) {
+		o.auditAllow = false
+	}
+}
+
+/************************
+ * Authorizer
+ ************************/
+
+// Authorizer 基于接口名的授权，策略可以在运行期替换
+type Authorizer struct {
+	logger *log.Helper
+	opts   *options
+	policy atomic.Pointer[Policy]
+}
+
+// NewAuthorizer 创建授权器，logger 用于记录审计日志
+func NewAuthorizer(logger log.Logger, policy Policy, opts ...Option) (*Authorizer, error) {
+	o := &options{
+		conditions: make(map[string]Condition),
+		auditAllow: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	a := &Authorizer{
+		logger: log.NewHelper(logger),
+		opts:   o,
+	}
+	if err := a.Update(policy); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update 校验并替换策略，校验失败时保留原策略
+func (a *Authorizer) Update(policy Policy) error {
+	if err := policy.validate(a.opts.conditions); err != nil {
+		a.logger.Errorw("msg", "authz policy rejected", "error", err)
+		return err
+	}
+	if a.policy.Swap(&policy) != nil {
+		a.logger.Infow("msg", "authz policy updated", "rules", len(policy.Rules))
+	}
+	return nil
+}
+
+// WatchPolicy 监听配置中 key 对应的策略，配置重新加载后调用 Update 热更新
+//
+// key 使用点分路径，如 "authz"；不合法的策略被拒绝并记录日志，继续使用原策略。
+func (a *Authorizer) WatchPolicy(key string) {
+	bootstrap.Watch(key, func(p Policy) {
+		_ = a.Update(p)
+	})
+}
+
+// Authorize 根据当前策略判断是否允许调用接口，claims 为 nil 表示未认证
+func (a *Authorizer) Authorize(ctx context.Context, operation string, claims *auth.Claims, req any) Decision {
+	p := a.policy.Load()
+
+	var allowed *Decision
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if !r.matches(ctx, operation, claims, req, a.opts.conditions) {
+			continue
+		}
+		d := Decision{Allowed: !r.deny(), Rule: r.ruleName(i)}
+		if p.Mode == ModeFirstMatch || r.deny() {
+			return d
+		}
+		if allowed == nil {
+			allowed = &d
+		}
+	}
+	if allowed != nil {
+		return *allowed
+	}
+	return Decision{Allowed: p.Default == EffectAllow, Rule: "default"}
+}
+
+/************************
+ * Middleware
+ ************************/
+
+// Server 返回授权中间件，需要放在 auth.Server 之后
+//
+// 未认证的请求被拒绝时返回 401，已认证的请求被拒绝时返回 403，每次决策都会记录审计日志。
+func Server(a *Authorizer) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			operation := tr.Operation()
+			claims, _ := auth.FromContext(ctx)
+
+			d := a.Authorize(ctx, operation, claims, req)
+			a.audit(ctx, operation, claims, d)
+
+			switch {
+			case d.Allowed:
+				return handler(ctx, req)
+			case claims == nil:
+				return nil, errors.Unauthorized(ReasonUnauthorized, "未登录或登录已过期")
+			default:
+				return nil, errors.Forbidden(ReasonForbidden, "无权访问")
+			}
+		}
+	}
+}
+
+// audit 记录授权决策
+func (a *Authorizer) audit(ctx context.Context, operation string, claims *auth.Claims, d Decision) {
+	if d.Allowed && !a.opts.auditAllow {
+		return
+	}
+
+	decision, level := "allow", log.LevelInfo
+	if !d.Allowed {
+		decision, level = "deny", log.LevelWarn
+	}
+	subject, method := "", "anonymous"
+	if claims != nil {
+		subject, method = claims.Subject, claims.Method
+	}
+	a.logger.WithContext(ctx).Log(level,
+		"msg", "authz decision",
+		"operation", operation,
+		"subject", subject,
+		"method", method,
+		"decision", decision,
+		"rule", d.Rule,
+	)
+}
@@ -0,0 +1,233 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/lhlyu/kratos-easy/middlewares/auth"
+)
+
+type updateReq struct {
+	OwnerID string
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := Policy{
+		Rules: []Rule{
+			{Name: "public", Operations: []string{"/api.v1.Public/*"}, Anonymous: true},
+			{Name: "admin", Operations: []string{"*"}, Roles: []string{"admin"}},
+			{Name: "readonly", Operations: []string{"*/Delete*"}, Effect: EffectDeny, Roles: []string{"readonly"}},
+			{Name: "reader", Operations: []string{"/api.v1.User/Get", "/api.v1.User/List"}, Permissions: []string{"user:read"}},
+			{Name: "tenant", Operations: []string{"/api.v1.Billing/*"}, Attributes: map[string]string{"tenant": "t1"}},
+			{Name: "owner", Operations: []string{"/api.v1.User/Update"}, Conditions: []string{"owner"}},
+		},
+	}
+	owner := func(_ context.Context, c *auth.Claims, req any) bool {
+		r, ok := req.(*updateReq)
+		return ok && r.OwnerID == c.Subject
+	}
+	a, err := NewAuthorizer(log.NewStdLogger(io.Discard), policy, WithCondition("owner", owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &auth.Claims{Subject: "a", Roles: []string{"admin"}}
+	readonlyAdmin := &auth.Claims{Subject: "b", Roles: []string{"admin", "readonly"}}
+	reader := &auth.Claims{Subject: "c", Scopes: []string{"user:read"}}
+	tenant := &auth.Claims{Subject: "d", Extra: map[string]any{"tenant": []any{"t0", "t1"}}}
+
+	cases := []struct {
+		name      string
+		operation string
+		claims    *auth.Claims
+		req       any
+		allowed   bool
+		rule      string
+	}{
+		{"anonymous public", "/api.v1.Public/Ping", nil, nil, true, "public"},
+		{"anonymous private", "/api.v1.User/Get", nil, nil, false, "default"},
+		{"admin", "/api.v1.User/DeleteAll", admin, nil, true, "admin"},
+		{"deny overrides allow", "/api.v1.User/DeleteAll", readonlyAdmin, nil, false, "readonly"},
+		{"permission", "/api.v1.User/List", reader, nil, true, "reader"},
+		{"missing permission", "/api.v1.User/Create", reader, nil, false, "default"},
+		{"attribute", "/api.v1.Billing/Get", tenant, nil, true, "tenant"},
+		{"owner", "/api.v1.User/Update", reader, &updateReq{OwnerID: "c"}, true, "owner"},
+		{"not owner", "/api.v1.User/Update", reader, &updateReq{OwnerID: "x"}, false, "default"},
+	}
+	for _, c := range cases {
+		d := a.Authorize(context.Background(), c.operation, c.claims, c.req)
+		if d.Allowed != c.allowed || d.Rule != c.rule {
+			t.Errorf("%s: got %+v, want allowed=%v rule=%s", c.name, d, c.allowed, c.rule)
+		}
+	}
+
+	// first-match 模式下按顺序使用第一条匹配的规则
+	policy.Mode = ModeFirstMatch
+	if err := a.Update(policy); err != nil {
+		t.Fatal(err)
+	}
+	if d := a.Authorize(context.Background(), "/api.v1.User/DeleteAll", readonlyAdmin, nil); !d.Allowed || d.Rule != "admin" {
+		t.Fatalf("first-match: got %+v", d)
+	}
+
+	// 不合法的策略被拒绝，保留原策略
+	if err := a.Update(Policy{Rules: []Rule{{Operations: []string{"*"}, Conditions: []string{"missing"}}}}); err == nil {
+		t.Fatal("expected invalid policy to be rejected")
+	}
+	if d := a.Authorize(context.Background(), "/api.v1.Public/Ping", nil, nil); !d.Allowed {
+		t.Fatalf("previous policy should be kept, got %+v", d)
+	}
+}
+
+type testTransport struct {
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return t.operation }
+func (t *testTransport) RequestHeader() transport.Header { return nil }
+func (t *testTransport) ReplyHeader() transport.Header   { return nil }
+
+// call 以指定身份调用中间件
+func call(m middleware.Handler, operation string, claims *auth.Claims) error {
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: operation})
+	if claims != nil {
+		ctx = auth.NewContext(ctx, claims)
+	}
+	_, err := m(ctx, nil)
+	return err
+}
+
+func ok(context.Context, any) (any, error) { return "ok", nil }
+
+func TestServer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a, err := NewAuthorizer(log.NewStdLogger(buf), Policy{
+		Rules: []Rule{
+			{Name: "public", Operations: []string{"/api.v1.Public/*"}, Anonymous: true},
+			{Name: "admin", Operations: []string{"/api.v1.Admin/*"}, Roles: []string{"admin"}},
+		},
+	}, WithAuditDenyOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Server(a)(ok)
+	user := &auth.Claims{Subject: "u1", Method: auth.MethodJWT}
+
+	if err := call(m, "/api.v1.Public/Ping", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := call(m, "/api.v1.Admin/Reset", &auth.Claims{Subject: "a", Roles: []string{"admin"}}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("allow decisions should not be audited: %s", buf.String())
+	}
+
+	// 未认证返回 401，已认证但无权限返回 403
+	if err := call(m, "/api.v1.Admin/Reset", nil); !errors.IsUnauthorized(err) || errors.Reason(err) != ReasonUnauthorized {
+		t.Fatalf("expected 401, got %v", err)
+	}
+	if err := call(m, "/api.v1.Admin/Reset", user); !errors.IsForbidden(err) || errors.Reason(err) != ReasonForbidden {
+		t.Fatalf("expected 403, got %v", err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"msg=authz decision operation=/api.v1.Admin/Reset subject= method=anonymous decision=deny rule=default",
+		"msg=authz decision operation=/api.v1.Admin/Reset subject=u1 method=jwt decision=deny rule=default",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing audit entry %q in:\n%s", s, out)
+		}
+	}
+	if !strings.Contains(out, "WARN") {
+		t.Errorf("deny should be audited at warn level:\n%s", out)
+	}
+
+	// 不在 transport 中的调用直接放行
+	if _, err := m(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerConcurrentUpdate(t *testing.T) {
+	allowAll := Policy{Default: EffectAllow}
+	denyAll := Policy{Rules: []Rule{{Name: "deny", Operations: []string{"*"}, Effect: EffectDeny}}}
+	a, err := NewAuthorizer(log.NewStdLogger(io.Discard), allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Server(a)(ok)
+	user := &auth.Claims{Subject: "u1"}
+
+	// 请求进行中反复切换策略
+	stop := make(chan struct{})
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			p := allowAll
+			if i%2 == 0 {
+				p = denyAll
+			}
+			if err := a.Update(p); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	var (
+		wg              sync.WaitGroup
+		allowed, denied atomic.Int32
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 至少调用 500 次，且两种策略都生效过
+			for n := 0; n < 100_000 && (n < 500 || allowed.Load() == 0 || denied.Load() == 0); n++ {
+				switch err := call(m, "/api.v1.User/Get", user); {
+				case err == nil:
+					allowed.Add(1)
+				case errors.IsForbidden(err):
+					denied.Add(1)
+				default:
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-updated
+
+	if allowed.Load() == 0 || denied.Load() == 0 {
+		t.Fatalf("expected both policies to take effect, allowed=%d denied=%d", allowed.Load(), denied.Load())
+	}
+
+	// 更新完成后新的请求立即使用新策略
+	if err := a.Update(denyAll); err != nil {
+		t.Fatal(err)
+	}
+	if err := call(m, "/api.v1.User/Get", user); !errors.IsForbidden(err) {
+		t.Fatalf("expected 403 after update, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/lhlyu/kratos-easy/middlewares/auth"
+)
+
+// 决策模式
+const (
+	// ModeDenyOverrides 任意匹配的 deny 规则优先于 allow 规则
+	ModeDenyOverrides = "deny-overrides"
+	// ModeFirstMatch 按顺序使用第一条匹配的规则
+	ModeFirstMatch = "first-match"
+)
+
+// 规则效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Policy 授权策略，可以直接放在配置文件中
+//
+//	authz:
+//	  mode: deny-overrides
+//	  rules:
+//	    - name: public
+//	      operations: ["/api.v1.Public/*"]
+//	      anonymous: true
+//	    - name: admin
+//	      operations: ["/api.v1.Admin/*"]
+//	      roles: ["admin"]
+//	    - name: no-delete-for-readonly
+//	      operations: ["*/Delete*"]
+//	      effect: deny
+//	      roles: ["readonly"]
+type Policy struct {
+	// Mode 决策模式，默认 deny-overrides
+	Mode string `json:"mode"`
+	// Default 没有规则匹配时的效果，默认 deny
+	Default string `json:"default"`
+	// Rules 规则列表
+	Rules []Rule `json:"rules"`
+}
+
+// Rule 授权规则，接口匹配且主体满足全部条件时生效
+type Rule struct {
+	// Name 规则名，记录在审计日志中
+	Name string `json:"name"`
+	// Operations 生效的接口，支持 * 通配符
+	Operations []string `json:"operations"`
+	// Effect 效果，allow 或 deny，默认 allow
+	Effect string `json:"effect"`
+	// Anonymous 是否匹配未认证的请求，为 true 时忽略其他条件
+	Anonymous bool `json:"anonymous"`
+	// Roles 拥有任意一个角色
+	Roles []string `json:"roles"`
+	// Permissions 拥有全部授权范围
+	Permissions []string `json:"permissions"`
+	// Subjects 主体在列表中
+	Subjects []string `json:"subjects"`
+	// Attributes 身份信息中的属性等于指定值，属性为数组时包含指定值即可
+	Attributes map[string]string `json:"attributes"`
+	// Conditions 全部通过的自定义条件，通过 WithCondition 注册
+	Conditions []string `json:"conditions"`
+}
+
+// Condition 自定义条件，用于根据请求内容判断，例如只能修改自己的数据
+type Condition func(ctx context.Context, claims *auth.Claims, req any) bool
+
+// validate 校验策略
+func (p *Policy) validate(conditions map[string]Condition) error {
+	switch p.Mode {
+	case "", ModeDenyOverrides, ModeFirstMatch:
+	default:
+		return fmt.Errorf("authz: unknown mode %q", p.Mode)
+	}
+	switch p.Default {
+	case "", EffectAllow, EffectDeny:
+	default:
+		return fmt.Errorf("authz: unknown default effect %q", p.Default)
+	}
+
+	for i, r := range p.Rules {
+		switch r.Effect {
+		case "", EffectAllow, EffectDeny:
+		default:
+			return fmt.Errorf("authz: rule %d %q: unknown effect %q", i, r.Name, r.Effect)
+		}
+		if len(r.Operations) == 0 {
+			return fmt.Errorf("authz: rule %d %q: operations is required", i, r.Name)
+		}
+		for _, name := range r.Conditions {
+			if _, ok := conditions[name]; !ok {
+				return fmt.Errorf("authz: rule %d %q: unknown condition %q", i, r.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ruleName 审计日志中的规则名，未命名时使用序号
+func (r *Rule) ruleName(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// deny 是否为 deny 规则
+func (r *Rule) deny() bool {
+	return r.Effect == EffectDeny
+}
+
+// matches 判断规则是否对当前请求生效
+func (r *Rule) matches(ctx context.Context, operation string, claims *auth.Claims, req any, conditions map[string]Condition) bool {
+	if !slices.ContainsFunc(r.Operations, func(pattern string) bool {
+		return auth.MatchOperation(pattern, operation)
+	}) {
+		return false
+	}
+	if r.Anonymous {
+		return true
+	}
+	if claims == nil {
+		return false
+	}
+
+	if len(r.Roles) > 0 && !slices.ContainsFunc(r.Roles, claims.HasRole) {
+		return false
+	}
+	for _, p := range r.Permissions {
+		if !claims.HasScope(p) {
+			return false
+		}
+	}
+	if len(r.Subjects) > 0 && !slices.Contains(r.Subjects, claims.Subject) {
+		return false
+	}
+	for k, v := range r.Attributes {
+		if !hasAttribute(claims.Extra[k], v) {
+			return false
+		}
+	}
+	for _, name := range r.Conditions {
+		if !conditions[name](ctx, claims, req) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAttribute 属性等于 want，属性为数组时包含 want 即可
+func hasAttribute(v any, want string) bool {
+	switch t := v.(type) {
+	case string:
+		return t == want
+	case []string:
+		return slices.Contains(t, want)
+	case []any:
+		return slices.ContainsFunc(t, func(item any) bool {
+			return fmt.Sprint(item) == want
+		})
+	case nil:
+		return false
+	default:
+		return fmt.Sprint(t) == want
+	}
+}
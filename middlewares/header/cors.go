@@ -0,0 +1,172 @@
+package header
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 跨域相关的请求头和响应头
+const (
+	headerOrigin           = "Origin"
+	headerVary             = "Vary"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+)
+
+// isPreflight 是否为跨域预检请求
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get(headerOrigin) != "" &&
+		r.Header.Get(headerRequestMethod) != ""
+}
+
+// applyPreflight 处理预检请求，不允许时不写入任何跨域响应头，由浏览器拦截
+func applyPreflight(w http.ResponseWriter, r *http.Request, opt *options) {
+	c := opt.cors
+	h := w.Header()
+	addVary(h, headerOrigin, headerRequestMethod, headerRequestHeaders)
+
+	origin := r.Header.Get(headerOrigin)
+	allowOrigin, ok := c.allowOrigin(origin)
+	if !ok {
+		return
+	}
+	method := strings.ToUpper(r.Header.Get(headerRequestMethod))
+	if !slices.Contains(c.methods, method) {
+		return
+	}
+	requested := splitHeaderList(r.Header.Get(headerRequestHeaders))
+	if !c.allowHeaders(requested) {
+		return
+	}
+
+	h.Set(headerAllowOrigin, allowOrigin)
+	h.Set(headerAllowMethods, strings.Join(c.methods, ", "))
+	if len(requested) > 0 {
+		h.Set(headerAllowHeaders, strings.Join(requested, ", "))
+	}
+	if c.credentials {
+		h.Set(headerAllowCredentials, "true")
+	}
+	if c.maxAge > 0 {
+		h.Set(headerMaxAge, strconv.Itoa(int(c.maxAge.Seconds())))
+	}
+}
+
+// applyCORS 为普通跨域请求写入响应头
+func applyCORS(h http.Header, r *http.Request, opt *options) {
+	c := opt.cors
+	if !c.allowAny() {
+		// 返回值随 Origin 变化，告诉缓存按 Origin 区分
+		addVary(h, headerOrigin)
+	}
+
+	origin := r.Header.Get(headerOrigin)
+	if origin == "" {
+		return
+	}
+	allowOrigin, ok := c.allowOrigin(origin)
+	if !ok {
+		return
+	}
+
+	h.Set(headerAllowOrigin, allowOrigin)
+	if c.credentials {
+		h.Set(headerAllowCredentials, "true")
+	}
+	exposed := c.exposedHeaders
+	if opt.enableRequestId && !slices.ContainsFunc(exposed, func(s string) bool {
+		return strings.EqualFold(s, opt.requestIdHeader)
+	}) {
+		exposed = append(slices.Clip(exposed), opt.requestIdHeader)
+	}
+	if len(exposed) > 0 {
+		h.Set(headerExposeHeaders, strings.Join(exposed, ", "))
+	}
+}
+
+// allowAny 是否允许所有来源
+func (c *corsOptions) allowAny() bool {
+	return slices.Contains(c.origins, "*")
+}
+
+// allowOrigin 返回 Access-Control-Allow-Origin 的值
+//
+// 允许所有来源时返回 *，其他情况返回请求的来源。
+func (c *corsOptions) allowOrigin(origin string) (string, bool) {
+	if c.allowAny() {
+		return "*", true
+	}
+	lower := strings.ToLower(origin)
+	for _, pattern := range c.origins {
+		if matchOrigin(pattern, lower) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// allowHeaders 请求头是否全部允许
+func (c *corsOptions) allowHeaders(requested []string) bool {
+	if len(c.headers) == 0 {
+		return true
+	}
+	for _, r := range requested {
+		if !slices.ContainsFunc(c.headers, func(s string) bool {
+			return strings.EqualFold(s, r)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchOrigin 匹配来源，pattern 中的 * 匹配一级或多级子域名
+func matchOrigin(pattern, origin string) bool {
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok {
+		return pattern == origin
+	}
+	if len(origin) <= len(prefix)+len(suffix) ||
+		!strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	// 通配部分只能是域名，不能包含端口或路径
+	sub := origin[len(prefix) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(sub, ":/")
+}
+
+// splitHeaderList 拆分逗号分隔的请求头列表
+func splitHeaderList(v string) []string {
+	var list []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// addVary 追加 Vary 响应头，已存在的值不重复追加
+func addVary(h http.Header, values ...string) {
+	existing := make(map[string]bool)
+	for _, v := range h.Values(headerVary) {
+		for _, s := range splitHeaderList(v) {
+			existing[strings.ToLower(s)] = true
+		}
+	}
+	for _, v := range values {
+		if !existing[strings.ToLower(v)] {
+			h.Add(headerVary, v)
+			existing[strings.ToLower(v)] = true
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package header
+
+import (
+	netHttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(t *testing.T, opts []Option, method, origin string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	next := netHttp.HandlerFunc(func(w netHttp.ResponseWriter, _ *netHttp.Request) {
+		w.WriteHeader(netHttp.StatusOK)
+	})
+	r := httptest.NewRequest(method, "/v1/users", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	Filter(opts...)(next).ServeHTTP(w, r)
+	return w
+}
+
+func TestFilterPreflight(t *testing.T) {
+	opts := []Option{
+		WithCORS("https://example.com", "https://*.example.org"),
+		WithCORSHeaders("Content-Type", "Authorization"),
+		WithCORSCredentials(),
+		WithCORSMaxAge(10 * time.Minute),
+	}
+
+	w := serve(t, opts, "OPTIONS", "https://app.example.org",
+		"Access-Control-Request-Method", "delete",
+		"Access-Control-Request-Headers", "content-type, authorization",
+	)
+	h := w.Header()
+	if w.Code != netHttp.StatusNoContent {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if h.Get(headerAllowOrigin) != "https://app.example.org" || h.Get(headerAllowCredentials) != "true" ||
+		h.Get(headerMaxAge) != "600" || h.Get(headerAllowHeaders) != "content-type, authorization" {
+		t.Fatalf("unexpected headers: %v", h)
+	}
+	if got := h.Values(headerVary); len(got) != 3 || got[0] != headerOrigin {
+		t.Fatalf("unexpected vary: %v", got)
+	}
+
+	rejected := map[string][]string{
+		"origin":       {"https://example.org", "Access-Control-Request-Method", "GET"},
+		"port":         {"https://a.example.org:8443", "Access-Control-Request-Method", "GET"},
+		"method":       {"https://example.com", "Access-Control-Request-Method", "CONNECT"},
+		"header":       {"https://example.com", "Access-Control-Request-Method", "GET", "Access-Control-Request-Headers", "X-Secret"},
+		"sub-suffix":   {"https://example.com.evil.io", "Access-Control-Request-Method", "GET"},
+		"wrong-scheme": {"http://example.com", "Access-Control-Request-Method", "GET"},
+	}
+	for name, args := range rejected {
+		w := serve(t, opts, "OPTIONS", args[0], args[1:]...)
+		if w.Code != netHttp.StatusNoContent || w.Header().Get(headerAllowOrigin) != "" {
+			t.Fatalf("%s: expected preflight to be rejected, got %d %v", name, w.Code, w.Header())
+		}
+	}
+}
+
+func TestFilterActualRequest(t *testing.T) {
+	w := serve(t, []Option{WithCORS("*"), WithCORSExposedHeaders("X-Total")}, "GET", "https://any.io")
+	h := w.Header()
+	if w.Code != netHttp.StatusOK || h.Get(headerAllowOrigin) != "*" || h.Get(headerVary) != "" {
+		t.Fatalf("unexpected response: %d %v", w.Code, h)
+	}
+	if h.Get(headerExposeHeaders) != "X-Total, x-request-id" {
+		t.Fatalf("unexpected exposed headers: %v", h.Get(headerExposeHeaders))
+	}
+
+	// 携带凭证时只返回明确允许的来源
+	opts := []Option{WithCORS("https://example.com"), WithCORSCredentials(), DisableRequestId()}
+	w = serve(t, opts, "GET", "https://example.com")
+	h = w.Header()
+	if h.Get(headerAllowOrigin) != "https://example.com" || h.Get(headerAllowCredentials) != "true" ||
+		h.Get(headerVary) != headerOrigin || h.Get(headerExposeHeaders) != "" {
+		t.Fatalf("unexpected headers: %v", h)
+	}
+	w = serve(t, opts, "GET", "https://any.io")
+	if w.Header().Get(headerAllowOrigin) != "" || w.Header().Get(headerAllowCredentials) != "" {
+		t.Fatalf("unexpected headers: %v", w.Header())
+	}
+
+	// 没有 Origin 的请求也要带上 Vary，避免缓存把无跨域头的响应返回给跨域请求
+	w = serve(t, []Option{WithCORS("https://example.com")}, "GET", "")
+	if w.Header().Get(headerVary) != headerOrigin || w.Header().Get(headerAllowOrigin) != "" {
+		t.Fatalf("unexpected headers: %v", w.Header())
+	}
+
+	// 未启用跨域时不处理
+	w = serve(t, nil, "OPTIONS", "https://example.com", "Access-Control-Request-Method", "GET")
+	if w.Code != netHttp.StatusOK || w.Header().Get(headerAllowOrigin) != "" {
+		t.Fatalf("unexpected response: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	for name, build := range map[string]func(...Option){
+		"filter": func(opts ...Option) { Filter(opts...) },
+		"header": func(opts ...Option) { Header(opts...) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected panic for * with credentials", name)
+				}
+			}()
+			build(WithCORSCredentials(), WithCORS("*"))
+		}()
+	}
+}
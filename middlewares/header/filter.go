@@ -0,0 +1,31 @@
+package header
+
+import (
+	netHttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// Filter 返回处理跨域的 HTTP 过滤器，通过 http.Filter 注册。
+//
+// 预检请求在过滤器中直接返回 204，不会进入路由；
+// 普通请求在进入路由前写入跨域响应头，路由不存在或返回错误时同样生效。
+// 未通过 WithCORS 启用跨域时不做任何处理。
+func Filter(opts ...Option) http.FilterFunc {
+	opt := newOptions(opts...)
+
+	return func(next netHttp.Handler) netHttp.Handler {
+		if opt.cors == nil {
+			return next
+		}
+		return netHttp.HandlerFunc(func(w netHttp.ResponseWriter, r *netHttp.Request) {
+			if isPreflight(r) {
+				applyPreflight(w, r, opt)
+				w.WriteHeader(netHttp.StatusNoContent)
+				return
+			}
+			applyCORS(w.Header(), r, opt)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 //   - 返回 x-request-id（基于 OpenTelemetry TraceID）
 //
 // 可通过 Option 自定义或禁用相关行为。
+// 跨域相关的 Option 需要同时传给 Filter，预检请求不会进入中间件。
 func Header(opts ...Option) middleware.Middleware {
 	opt := newOptions(opts...)
 
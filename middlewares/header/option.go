@@ -1,12 +1,31 @@
 package header
 
+import (
+	"strings"
+	"time"
+)
+
 const defaultRequestIDHeader = "x-request-id"
 
+// defaultCORSMethods 默认允许的跨域请求方法
+var defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
+
 // options 定义响应头中间件的配置项
 type options struct {
 	enableRequestId bool
 	requestIdHeader string
 	staticHeaders   map[string]string
+	cors            *corsOptions
+}
+
+// corsOptions 定义跨域配置，为 nil 表示不处理跨域
+type corsOptions struct {
+	origins        []string
+	methods        []string
+	headers        []string // 为空时允许预检请求中的所有请求头
+	exposedHeaders []string
+	credentials    bool
+	maxAge         time.Duration
 }
 
 // Option 定义配置函数
@@ -22,6 +41,10 @@ func newOptions(opts ...Option) *options {
 	for _, opt := range opts {
 		opt(o)
 	}
+	// 允许所有来源时携带凭证，任意网站都能以用户身份调用接口并读取响应
+	if o.cors != nil && o.cors.credentials && o.cors.allowAny() {
+		panic("header: WithCORSCredentials cannot be used with WithCORS(\"*\"), list the allowed origins explicitly")
+	}
 	return o
 }
 
@@ -49,3 +72,69 @@ func WithStaticHeader(key, value string) Option {
 		}
 	}
 }
+
+// WithCORS 启用跨域，origins 为允许的来源，支持：
+//   - "*"：允许所有来源
+//   - "https://example.com"：精确匹配
+//   - "https://*.example.com"：匹配所有子域名，不包括 example.com 本身
+//
+// 预检请求需要通过 Filter 处理。
+func WithCORS(origins ...string) Option {
+	return func(o *options) {
+		c := o.corsOptions()
+		for _, origin := range origins {
+			c.origins = append(c.origins, strings.ToLower(origin))
+		}
+	}
+}
+
+// WithCORSMethods 设置允许的跨域请求方法，默认 GET、POST、PUT、PATCH、DELETE、HEAD
+func WithCORSMethods(methods ...string) Option {
+	return func(o *options) {
+		c := o.corsOptions()
+		c.methods = c.methods[:0]
+		for _, m := range methods {
+			c.methods = append(c.methods, strings.ToUpper(m))
+		}
+	}
+}
+
+// WithCORSHeaders 设置允许的跨域请求头，默认允许预检请求中的所有请求头
+func WithCORSHeaders(headers ...string) Option {
+	return func(o *options) {
+		c := o.corsOptions()
+		c.headers = append(c.headers, headers...)
+	}
+}
+
+// WithCORSExposedHeaders 设置允许前端读取的响应头，启用 Request Id 时会自动加入
+func WithCORSExposedHeaders(headers ...string) Option {
+	return func(o *options) {
+		c := o.corsOptions()
+		c.exposedHeaders = append(c.exposedHeaders, headers...)
+	}
+}
+
+// WithCORSCredentials 允许跨域请求携带 Cookie 等凭证，不能与 WithCORS("*") 同时使用
+func WithCORSCredentials() Option {
+	return func(o *options) {
+		o.corsOptions().credentials = true
+	}
+}
+
+// WithCORSMaxAge 设置预检请求结果的缓存时间
+func WithCORSMaxAge(d time.Duration) Option {
+	return func(o *options) {
+		o.corsOptions().maxAge = d
+	}
+}
+
+// corsOptions 返回跨域配置，不存在时创建
+func (o *options) corsOptions() *corsOptions {
+	if o.cors == nil {
+		o.cors = &corsOptions{
+			methods: append([]string(nil), defaultCORSMethods...),
+		}
+	}
+	return o.cors
+}
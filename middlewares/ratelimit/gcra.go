@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit 限流规则：每 Period 允许 Rate 个请求，最多可以突发 Burst 个
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// PerSecond 每秒 n 个请求，突发 n 个
+func PerSecond(n int) Limit {
+	return Limit{Rate: n, Period: time.Second, Burst: n}
+}
+
+// PerMinute 每分钟 n 个请求，突发 n 个
+func PerMinute(n int) Limit {
+	return Limit{Rate: n, Period: time.Minute, Burst: n}
+}
+
+// PerHour 每小时 n 个请求，突发 n 个
+func PerHour(n int) Limit {
+	return Limit{Rate: n, Period: time.Hour, Burst: n}
+}
+
+// valid 判断规则是否有效
+func (l Limit) valid() bool {
+	return l.Rate > 0 && l.Period > 0 && l.Burst > 0
+}
+
+// result 一次限流判断的结果
+type result struct {
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration // 被拒绝时距离下次允许的时间
+	resetAfter time.Duration // 距离额度完全恢复的时间
+}
+
+// gcraScript GCRA 限流，Redis 中只保存理论到达时间（TAT，毫秒）
+//
+// 使用 Redis 服务器时间，避免各副本时钟不一致。
+// 返回 {allowed, remaining, retry_after_ms, reset_after_ms}。
+var gcraScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + tonumber(t[2]) / 1000
+local emission = period / rate
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local diff = now - (new_tat - tolerance)
+if diff < 0 then
+  return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+local reset_after = new_tat - now
+redis.call('SET', KEYS[1], tostring(new_tat), 'PX', math.ceil(reset_after))
+return {1, math.floor(diff / emission), 0, math.ceil(reset_after)}
+`)
+
+// allow 消耗一个额度
+func allow(ctx context.Context, client redis.UniversalClient, key string, l Limit) (*result, error) {
+	values, err := gcraScript.Run(ctx, client, []string{key}, l.Burst, l.Rate, l.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		allowed:    values[0] == 1,
+		remaining:  int(values[1]),
+		retryAfter: time.Duration(values[2]) * time.Millisecond,
+		resetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
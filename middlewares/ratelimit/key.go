@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"google.golang.org/grpc/peer"
+)
+
+// KeyFunc 从请求中提取限流的 key，返回空字符串表示不限流
+type KeyFunc func(ctx context.Context) string
+
+// ByIP 按客户端 IP 限流，默认使用连接的对端地址
+//
+// X-Forwarded-For、X-Real-IP 可以被客户端伪造，只有对端地址属于 trustedProxies 时才会读取：
+// 从 X-Forwarded-For 的最右侧开始跳过可信代理，取第一个不可信的地址，没有 X-Forwarded-For 时读取 X-Real-IP。
+// trustedProxies 支持 IP 和 CIDR，例如 10.0.0.0/8，格式错误时 panic。
+func ByIP(trustedProxies ...string) KeyFunc {
+	trusted := make([]netip.Prefix, 0, len(trustedProxies))
+	for _, s := range trustedProxies {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, e := netip.ParseAddr(s)
+			if e != nil {
+				panic(fmt.Sprintf("ratelimit: invalid trusted proxy %q", s))
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	isTrusted := func(ip netip.Addr) bool {
+		return slices.ContainsFunc(trusted, func(p netip.Prefix) bool { return p.Contains(ip) })
+	}
+
+	return func(ctx context.Context) string {
+		ip := remoteIP(ctx)
+		if !ip.IsValid() {
+			return ""
+		}
+		if isTrusted(ip) {
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				if client := forwardedIP(tr.RequestHeader(), isTrusted); client.IsValid() {
+					ip = client
+				}
+			}
+		}
+		return "ip:" + ip.String()
+	}
+}
+
+// remoteIP 连接的对端地址
+func remoteIP(ctx context.Context) netip.Addr {
+	var addr string
+	if r, ok := http.RequestFromServerContext(ctx); ok {
+		addr = r.RemoteAddr
+	} else if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip, _ := netip.ParseAddr(addr)
+	return ip.Unmap()
+}
+
+// forwardedIP 从代理头中读取客户端地址，格式错误时返回零值
+func forwardedIP(h transport.Header, isTrusted func(netip.Addr) bool) netip.Addr {
+	var hops []string
+	for _, v := range h.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) == 0 {
+		ip, _ := netip.ParseAddr(strings.TrimSpace(h.Get("X-Real-IP")))
+		return ip.Unmap()
+	}
+
+	var ip netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !isTrusted(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// ByHeader 按请求头的值限流，请求头为空时不限流
+func ByHeader(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		tr, ok := transport.FromServerContext(ctx)
+		if !ok {
+			return ""
+		}
+		if v := tr.RequestHeader().Get(name); v != "" {
+			return strings.ToLower(name) + ":" + v
+		}
+		return ""
+	}
+}
+
+// ByOperation 按接口限流，所有调用方共享同一个额度
+func ByOperation() KeyFunc {
+	return func(ctx context.Context) string {
+		if tr, ok := transport.FromServerContext(ctx); ok {
+			return "op:" + tr.Operation()
+		}
+		return ""
+	}
+}
+
+// BySubject 按认证主体限流，例如 JWT 的 sub，subject 返回空字符串时不限流
+//
+// 需要放在认证中间件之后。
+func BySubject(subject func(ctx context.Context) string) KeyFunc {
+	return func(ctx context.Context) string {
+		if sub := subject(ctx); sub != "" {
+			return "sub:" + sub
+		}
+		return ""
+	}
+}
+
+// Join 组合多个 KeyFunc，例如按用户和接口限流，任意一个为空时不限流
+func Join(fns ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) string {
+		parts := make([]string, len(fns))
+		for i, fn := range fns {
+			if parts[i] = fn(ctx); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/redis/go-redis/v9"
+)
+
+// 限流相关的响应头
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderPolicy     = "RateLimit-Policy"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// 被限流时返回的错误
+const (
+	Reason  = "RATELIMIT"
+	Message = "请求过于频繁，请稍后再试"
+)
+
+/************************
+ * Option & Config
+ ************************/
+
+// options 定义限流中间件的配置项
+type options struct {
+	prefix     string
+	key        KeyFunc
+	limit      Limit
+	operations map[string]Limit
+	failClosed bool
+}
+
+// Option 定义配置函数
+type Option func(*options)
+
+// newOptions 初始化配置
+func newOptions(opts ...Option) *options {
+	o := &options{
+		prefix:     "ratelimit:",
+		key:        ByIP(),
+		limit:      PerSecond(100),
+		operations: make(map[string]Limit),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithPrefix 设置 Redis key 的前缀，默认 ratelimit:
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithKey 设置限流 key 的提取方式，默认 ByIP
+func WithKey(fn KeyFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.key = fn
+		}
+	}
+}
+
+// WithLimit 设置默认限流规则，默认每秒 100 个请求
+func WithLimit(l Limit) Option {
+	return func(o *options) {
+		if l.valid() {
+			o.limit = l
+		}
+	}
+}
+
+// WithOperationLimit 为指定接口单独设置限流规则，额度与默认规则分开计算
+func WithOperationLimit(operation string, l Limit) Option {
+	return func(o *options) {
+		if l.valid() {
+			o.operations[operation] = l
+		}
+	}
+}
+
+// WithFailClosed Redis 不可用时拒绝请求，默认放行
+func WithFailClosed() Option {
+	return func(o *options) {
+		o.failClosed = true
+	}
+}
+
+/************************
+ * Middleware
+ ************************/
+
+// Server 返回基于 Redis 的分布式限流中间件，多个副本共享额度
+//
+// 使用 GCRA 算法，响应中写入 RateLimit-* 响应头，被限流时返回 429 错误并写入 Retry-After。
+func Server(logger log.Logger, client redis.UniversalClient, opts ...Option) middleware.Middleware {
+	o := newOptions(opts...)
+	helper := log.NewHelper(logger)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			key := o.key(ctx)
+			if key == "" {
+				return handler(ctx, req)
+			}
+
+			limit, redisKey := o.limit, o.prefix+key
+			if l, ok := o.operations[tr.Operation()]; ok {
+				limit, redisKey = l, o.prefix+tr.Operation()+"|"+key
+			}
+
+			res, err := allow(ctx, client, redisKey, limit)
+			if err != nil {
+				helper.WithContext(ctx).Warnw("msg", "rate limit failed", "key", redisKey, "error", err)
+				if o.failClosed {
+					return nil, errors.ServiceUnavailable(Reason, "服务繁忙，请稍后再试").WithCause(err)
+				}
+				return handler(ctx, req)
+			}
+
+			setHeaders(tr.ReplyHeader(), limit, res)
+			if !res.allowed {
+				return nil, errors.New(429, Reason, Message)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+// setHeaders 写入 RateLimit-* 响应头，时间单位为秒
+func setHeaders(h transport.Header, l Limit, res *result) {
+	h.Set(HeaderLimit, strconv.Itoa(l.Burst))
+	h.Set(HeaderRemaining, strconv.Itoa(res.remaining))
+	h.Set(HeaderReset, seconds(res.resetAfter))
+	h.Set(HeaderPolicy, strconv.Itoa(l.Rate)+";w="+seconds(l.Period)+";burst="+strconv.Itoa(l.Burst))
+	if !res.allowed {
+		h.Set(HeaderRetryAfter, seconds(res.retryAfter))
+	}
+}
+
+// seconds 向上取整为秒
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
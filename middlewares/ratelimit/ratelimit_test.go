@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net"
+	nethttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/peer"
+)
+
+type headerCarrier nethttp.Header
+
+func (h headerCarrier) Get(key string) string      { return nethttp.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { nethttp.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { nethttp.Header(h).Add(key, value) }
+func (h headerCarrier) Values(key string) []string { return nethttp.Header(h).Values(key) }
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type testTransport struct {
+	operation string
+	req, rep  headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return t.operation }
+func (t *testTransport) RequestHeader() transport.Header { return t.req }
+func (t *testTransport) ReplyHeader() transport.Header   { return t.rep }
+
+func call(t *testing.T, m func(ctx context.Context, req any) (any, error), operation, ip string) (*testTransport, error) {
+	t.Helper()
+	tr := &testTransport{operation: operation, req: headerCarrier{}, rep: headerCarrier{}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50051}})
+	_, err := m(transport.NewServerContext(ctx, tr), nil)
+	return tr, err
+}
+
+func TestServer(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	m := Server(log.NewStdLogger(io.Discard), client,
+		WithLimit(Limit{Rate: 2, Period: time.Minute, Burst: 2}),
+		WithOperationLimit("/api.v1.User/Login", PerMinute(1)),
+	)(func(context.Context, any) (any, error) { return "ok", nil })
+
+	for i := range 2 {
+		tr, err := call(t, m, "/api.v1.User/Get", "1.1.1.1")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if got := tr.rep.Get(HeaderRemaining); got != []string{"1", "0"}[i] {
+			t.Fatalf("request %d: remaining = %s", i, got)
+		}
+	}
+
+	tr, err := call(t, m, "/api.v1.User/Get", "1.1.1.1")
+	if se := errors.FromError(err); se == nil || se.Code != 429 || se.Reason != Reason {
+		t.Fatalf("expected 429, got %v", err)
+	}
+	if tr.rep.Get(HeaderRetryAfter) != "30" || tr.rep.Get(HeaderLimit) != "2" {
+		t.Fatalf("unexpected headers: %v", tr.rep)
+	}
+
+	// 不同 IP 的额度互不影响
+	if _, err := call(t, m, "/api.v1.User/Get", "2.2.2.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 单独设置的接口额度与默认额度分开计算
+	if _, err := call(t, m, "/api.v1.User/Login", "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call(t, m, "/api.v1.User/Login", "1.1.1.1"); errors.Code(err) != 429 {
+		t.Fatalf("expected 429, got %v", err)
+	}
+}
+
+func TestServerFailOpen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+	mr.Close()
+
+	next := func(context.Context, any) (any, error) { return "ok", nil }
+	logger := log.NewStdLogger(io.Discard)
+
+	if _, err := call(t, Server(logger, client)(next), "/op", "1.1.1.1"); err != nil {
+		t.Fatalf("expected fail open, got %v", err)
+	}
+	if _, err := call(t, Server(logger, client, WithFailClosed())(next), "/op", "1.1.1.1"); errors.Code(err) != 503 {
+		t.Fatalf("expected 503, got %v", err)
+	}
+}
+
+func TestByIP(t *testing.T) {
+	key := func(fn KeyFunc, remote string, headers ...string) string {
+		tr := &testTransport{req: headerCarrier{}, rep: headerCarrier{}}
+		for i := 0; i+1 < len(headers); i += 2 {
+			tr.req.Add(headers[i], headers[i+1])
+		}
+		addr, _ := net.ResolveTCPAddr("tcp", remote)
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		return fn(transport.NewServerContext(ctx, tr))
+	}
+
+	trusted := ByIP("10.0.0.0/8", "192.168.1.1")
+	cases := []struct {
+		name    string
+		fn      KeyFunc
+		remote  string
+		headers []string
+		want    string
+	}{
+		{"default ignores headers", ByIP(), "1.1.1.1:1234", []string{"X-Forwarded-For", "9.9.9.9", "X-Real-IP", "8.8.8.8"}, "ip:1.1.1.1"},
+		{"untrusted peer", trusted, "1.1.1.1:1234", []string{"X-Forwarded-For", "9.9.9.9"}, "ip:1.1.1.1"},
+		{"rightmost untrusted hop", trusted, "10.0.0.2:1234", []string{"X-Forwarded-For", "6.6.6.6, 2.2.2.2, 10.0.0.3"}, "ip:2.2.2.2"},
+		{"multiple headers", trusted, "192.168.1.1:1234", []string{"X-Forwarded-For", "6.6.6.6", "X-Forwarded-For", "3.3.3.3"}, "ip:3.3.3.3"},
+		{"real ip", trusted, "10.0.0.2:1234", []string{"X-Real-IP", "4.4.4.4"}, "ip:4.4.4.4"},
+		{"invalid header", trusted, "10.0.0.2:1234", []string{"X-Forwarded-For", "bogus"}, "ip:10.0.0.2"},
+		{"ipv6 peer", ByIP(), "[::1]:1234", nil, "ip:::1"},
+	}
+	for _, c := range cases {
+		if got := key(c.fn, c.remote, c.headers...); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for invalid trusted proxy")
+		}
+	}()
+	ByIP("not-an-ip")
+}
@@ -0,0 +1,166 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/lhlyu/kratos-easy/constants"
+	"github.com/lhlyu/kratos-easy/httpx"
+	"github.com/lhlyu/kratos-easy/utilx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultMessage 返回给客户端的默认提示
+const defaultMessage = "服务器异常"
+
+// Panic 一次 panic 的现场信息
+type Panic struct {
+	// Value recover 得到的值
+	Value any
+	// Stack 发生 panic 的 goroutine 堆栈，最多 64KB
+	Stack []byte
+	// Operation 正在处理的接口
+	Operation string
+	// TraceID 链路追踪 ID，没有链路信息时为空
+	TraceID string
+	// Time 发生时间
+	Time time.Time
+}
+
+// Sink 上报 panic，例如发送到 Sentry 或告警群，在请求协程中同步调用，需要尽快返回
+type Sink interface {
+	Report(ctx context.Context, p *Panic)
+}
+
+// SinkFunc 函数形式的 Sink
+type SinkFunc func(ctx context.Context, p *Panic)
+
+// Report 实现 Sink
+func (f SinkFunc) Report(ctx context.Context, p *Panic) {
+	f(ctx, p)
+}
+
+/************************
+ * Option & Config
+ ************************/
+
+// options 定义恢复中间件的配置项
+type options struct {
+	sinks      []Sink
+	message    string
+	debugStack bool
+}
+
+// Option 定义配置函数
+type Option func(*options)
+
+// newOptions 初始化配置
+func newOptions(opts ...Option) *options {
+	o := &options{
+		message: defaultMessage,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSink 添加 panic 上报
+func WithSink(sinks ...Sink) Option {
+	return func(o *options) {
+		o.sinks = append(o.sinks, sinks...)
+	}
+}
+
+// WithMessage 设置返回给客户端的提示，默认 服务器异常
+func WithMessage(msg string) Option {
+	return func(o *options) {
+		if msg != "" {
+			o.message = msg
+		}
+	}
+}
+
+// WithDebugStack 在本地调试和测试环境（constants.IsDebug）下，
+// 把 panic 的值和堆栈放在错误的 metadata 中返回给客户端，其他环境不受影响
+//
+// metadata 会通过 httpx.EncodeError 输出到 data，gRPC 客户端则会在 status details 中收到。
+func WithDebugStack() Option {
+	return func(o *options) {
+		o.debugStack = true
+	}
+}
+
+/************************
+ * Middleware
+ ************************/
+
+// Recovery 返回恢复中间件，捕获 handler 中的 panic 并转换为 500 错误
+//
+// 堆栈以 Error 等级记录到日志（包含 trace_id），并同步上报到 Sink。
+// 默认返回给客户端的错误不包含任何 panic 信息，调试时可以通过 WithDebugStack 开启。
+func Recovery(logger log.Logger, opts ...Option) middleware.Middleware {
+	o := newOptions(opts...)
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (reply any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = o.recover(ctx, logger, r, utilx.Stack())
+					reply = nil
+				}
+			}()
+			return handler(ctx, req)
+		}
+	}
+}
+
+// recover 记录、上报 panic 并生成返回给客户端的错误
+func (o *options) recover(ctx context.Context, logger log.Logger, r any, stack []byte) error {
+	p := &Panic{
+		Value: r,
+		Stack: stack,
+		Time:  time.Now(),
+	}
+	if info, ok := transport.FromServerContext(ctx); ok {
+		p.Operation = info.Operation()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+
+	log.NewHelper(log.WithContext(ctx, logger)).Errorw(
+		"msg", "panic recovered",
+		"operation", p.Operation,
+		"panic", fmt.Sprint(r),
+		"stack", string(stack),
+	)
+
+	for _, sink := range o.sinks {
+		report(ctx, logger, sink, p)
+	}
+
+	se := errors.InternalServer(httpx.ReasonPanic, o.message)
+	if o.debugStack && constants.IsDebug() {
+		se = se.WithMetadata(map[string]string{
+			"panic": fmt.Sprint(r),
+			"stack": string(stack),
+		})
+	}
+	return se
+}
+
+// report 调用 Sink，Sink 自身的 panic 不会影响请求
+func report(ctx context.Context, logger log.Logger, sink Sink, p *Panic) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.NewHelper(log.WithContext(ctx, logger)).Errorw("msg", "panic sink failed", "panic", fmt.Sprint(r))
+		}
+	}()
+	sink.Report(ctx, p)
+}
@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/constants"
+	"github.com/lhlyu/kratos-easy/httpx"
+)
+
+func boom(context.Context, any) (any, error) {
+	panic("boom")
+}
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		debug bool
+		leak  bool
+	}{
+		{"development", constants.EnvDevelopment, false, false},
+		{"development-debug", constants.EnvDevelopment, true, true},
+		{"staging-debug", constants.EnvStaging, true, false},
+		{"production-debug", constants.EnvProduction, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(constants.AppEnv, tt.env)
+
+			var (
+				logs     bytes.Buffer
+				reported *Panic
+			)
+			opts := []Option{
+				WithSink(SinkFunc(func(_ context.Context, p *Panic) { reported = p })),
+				WithSink(SinkFunc(func(context.Context, *Panic) { panic("sink") })),
+			}
+			if tt.debug {
+				opts = append(opts, WithDebugStack())
+			}
+			m := Recovery(log.NewStdLogger(&logs), opts...)(boom)
+
+			_, err := m(context.Background(), nil)
+			se := errors.FromError(err)
+			if se.Code != 500 || se.Reason != httpx.ReasonPanic || se.Message != defaultMessage {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reported == nil || reported.Value != "boom" || !bytes.Contains(reported.Stack, []byte("recovery.boom")) {
+				t.Fatalf("unexpected report: %+v", reported)
+			}
+			if !strings.Contains(logs.String(), "panic recovered") || !strings.Contains(logs.String(), "panic sink failed") {
+				t.Fatalf("unexpected logs: %s", logs.String())
+			}
+
+			w := httptest.NewRecorder()
+			httpx.EncodeError(w, httptest.NewRequest("GET", "/", nil), err)
+			var body struct {
+				Code int32             `json:"code"`
+				Msg  string            `json:"msg"`
+				Data map[string]string `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != 500 || body.Code != 500 || body.Msg != defaultMessage {
+				t.Fatalf("unexpected response: %d %s", w.Code, w.Body.String())
+			}
+
+			leaked := strings.Contains(w.Body.String(), "recovery.boom")
+			if !tt.leak && (leaked || body.Data != nil || len(se.Metadata) > 0) {
+				t.Fatalf("stack leaked: %s", w.Body.String())
+			}
+			if tt.leak && (!leaked || body.Data["panic"] != "boom") {
+				t.Fatalf("expected stack: %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/healthx"
+	"github.com/lhlyu/kratos-easy/metricsx"
+)
+
+// Balance 从库负载均衡策略
+type Balance int
+
+const (
+	// RoundRobin 轮询
+	RoundRobin Balance = iota
+	// LeastConn 选择使用中连接数最少的从库
+	LeastConn
+)
+
+// primaryKey 在 context 中标记强制读主库
+type primaryKey struct{}
+
+// WithPrimary 返回强制读主库的 context，用于写入后需要立即读到最新数据的场景
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimary 判断 context 是否被标记为强制读主库
+func IsPrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// replica 从库及其健康状态
+type replica struct {
+	db      *sql.DB
+	name    string
+	healthy atomic.Bool
+	fails   int // 连续失败次数，同一时间只有一个检查协程访问
+}
+
+// newReplica 检查从库连接，不可用时以摘除状态加入集群
+func newReplica(l *log.Helper, db *sql.DB, name string, o *options) *replica {
+	r := &replica{db: db, name: name}
+	if err := pingDB(db, o.checkInterval); err != nil {
+		r.fails = o.ejectAfter
+		l.Warnw("msg", "replica unavailable, ejected", "db", name, "error", err)
+		return r
+	}
+	r.healthy.Store(true)
+	return r
+}
+
+// newReplicas 并发检查所有从库，启动耗时最多为一个检查间隔，不受不可用从库数量影响
+func newReplicas(l *log.Helper, dbs []*sql.DB, names []string, o *options) []*replica {
+	replicas := make([]*replica, len(dbs))
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replicas[i] = newReplica(l, db, names[i], o)
+		}()
+	}
+	wg.Wait()
+	return replicas
+}
+
+// Cluster 主从集群客户端
+//
+// 写请求和事务使用主库，读请求按负载均衡策略分发到健康的从库，
+// 没有可用从库或 context 标记了 WithPrimary 时读主库。
+type Cluster struct {
+	primary  *sql.DB
+	replicas []*replica
+	balance  Balance
+	next     atomic.Uint64
+}
+
+// NewCluster 新建主从集群客户端
+//
+// 连接池配置、埋点等 Option 对主库和每个从库分别生效。
+// 从库会定期检查，连续失败后摘除，恢复后重新加入；启动时连接不上的从库先摘除，不会导致创建失败。
+func NewCluster(logger log.Logger, primary string, replicas []string, opts ...Option) (*Cluster, func(), error) {
+	l := log.NewHelper(logger)
+
+	o := newOptions(opts...)
+
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	open := func(source string, connect func(log.Logger, string, *options) (*sql.DB, string, error)) (*sql.DB, string, error) {
+		db, name, err := connect(logger, source, o)
+		if err != nil {
+			return nil, "", err
+		}
+		unregisterMetrics := func() {}
+		if o.enableMetrics {
+			unregisterMetrics = metricsx.RegisterDB(name, db)
+		}
+		closers = append(closers, func() {
+			unregisterMetrics()
+			if err := db.Close(); err != nil {
+				l.Errorw("msg", "close database failed", "db", name, "error", err)
+			}
+		})
+		return db, name, nil
+	}
+
+	primaryDB, primaryName, err := open(primary, openDB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := &Cluster{
+		primary: primaryDB,
+		balance: o.balance,
+	}
+	// 从库不可用时不影响启动，先摘除，由健康检查恢复后重新加入
+	dbs := make([]*sql.DB, 0, len(replicas))
+	names := make([]string, 0, len(replicas))
+	for _, source := range replicas {
+		db, name, err := open(source, newDB)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		dbs = append(dbs, db)
+		names = append(names, name)
+	}
+	c.replicas = newReplicas(l, dbs, names, o)
+
+	l.Infow("msg", "database cluster connected", "primary", primaryName, "replicas", len(c.replicas))
+
+	// 只有主库影响就绪状态，从库不可用时会回退到主库
+	unregisterHealth := func() {}
+	if o.enableHealth {
+		unregisterHealth = healthx.Register(primaryName, primaryDB.PingContext)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	if len(c.replicas) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.watchReplicas(l, o, stop)
+		}()
+	}
+
+	cleanup := func() {
+		close(stop)
+		wg.Wait()
+		unregisterHealth()
+		closeAll()
+	}
+
+	return c, cleanup, nil
+}
+
+// Primary 返回主库
+func (c *Cluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Replica 返回一个健康的从库，没有可用从库或 context 标记了 WithPrimary 时返回主库
+func (c *Cluster) Replica(ctx context.Context) *sql.DB {
+	if IsPrimary(ctx) {
+		return c.primary
+	}
+	if r := c.pick(); r != nil {
+		return r.db
+	}
+	return c.primary
+}
+
+// Writer 返回写请求使用的查询对象，ctx 中有该集群的事务时返回事务
+func (c *Cluster) Writer(ctx context.Context) DBTX {
+	if st, ok := ctx.Value(txKey{db: c.primary}).(*txState); ok {
+		return st.tx
+	}
+	return c.primary
+}
+
+// Reader 返回读请求使用的查询对象，ctx 中有该集群的事务时返回事务以保证一致性
+func (c *Cluster) Reader(ctx context.Context) DBTX {
+	if st, ok := ctx.Value(txKey{db: c.primary}).(*txState); ok {
+		return st.tx
+	}
+	return c.Replica(ctx)
+}
+
+// NewTransactor 新建主库上的事务管理器
+func (c *Cluster) NewTransactor(opts ...TxOption) *Transactor {
+	return NewTransactor(c.primary, opts...)
+}
+
+// pick 按负载均衡策略选择健康的从库，没有时返回 nil
+func (c *Cluster) pick() *replica {
+	n := len(c.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	if c.balance == LeastConn {
+		var (
+			best  *replica
+			inUse int
+		)
+		for _, r := range c.replicas {
+			if !r.healthy.Load() {
+				continue
+			}
+			if u := r.db.Stats().InUse; best == nil || u < inUse {
+				best, inUse = r, u
+			}
+		}
+		return best
+	}
+
+	start := int(c.next.Add(1) % uint64(n))
+	for i := range n {
+		r := c.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// watchReplicas 定期检查从库，直到 stop 关闭
+func (c *Cluster) watchReplicas(l *log.Helper, o *options, stop <-chan struct{}) {
+	ticker := time.NewTicker(o.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		c.checkReplicas(l, o)
+	}
+}
+
+// checkReplicas 并发检查所有从库，连续失败达到阈值后摘除，检查成功后恢复
+//
+// 每个从库的超时时间为检查间隔，一轮检查最多耗时一个间隔，不受从库数量影响。
+func (c *Cluster) checkReplicas(l *log.Helper, o *options) {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.check(l, o)
+		}()
+	}
+	wg.Wait()
+}
+
+// check 检查一次从库并更新健康状态
+func (r *replica) check(l *log.Helper, o *options) {
+	err := pingDB(r.db, o.checkInterval)
+	if err == nil {
+		r.fails = 0
+		if !r.healthy.Load() {
+			r.healthy.Store(true)
+			l.Infow("msg", "replica readmitted", "db", r.name)
+		}
+		return
+	}
+
+	r.fails++
+	if r.healthy.Load() && r.fails >= o.ejectAfter {
+		r.healthy.Store(false)
+		l.Warnw("msg", "replica ejected", "db", r.name, "fails", r.fails, "error", err)
+	}
+}
@@ -0,0 +1,173 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// flakyServer 可以模拟宕机的数据库
+type flakyServer struct {
+	*fakeServer
+	down atomic.Bool
+	hang bool // 宕机时连接一直阻塞到超时，模拟网络不可达
+}
+
+func (s *flakyServer) Connect(ctx context.Context) (driver.Conn, error) {
+	if s.down.Load() {
+		if s.hang {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("connection refused")
+	}
+	return s.fakeServer.Connect(ctx)
+}
+
+func newTestCluster(t *testing.T, balance Balance, n int) (*Cluster, []*flakyServer) {
+	t.Helper()
+	servers := make([]*flakyServer, n)
+	c := &Cluster{primary: sql.OpenDB(newFakeServer()), balance: balance}
+	for i := range servers {
+		servers[i] = &flakyServer{fakeServer: newFakeServer()}
+		r := &replica{db: sql.OpenDB(servers[i]), name: "replica"}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	t.Cleanup(func() {
+		_ = c.primary.Close()
+		for _, r := range c.replicas {
+			_ = r.db.Close()
+		}
+	})
+	return c, servers
+}
+
+func TestClusterRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCluster(t, RoundRobin, 3)
+
+	seen := make(map[*sql.DB]int)
+	for range 6 {
+		seen[c.Replica(ctx)]++
+	}
+	if len(seen) != 3 || seen[c.replicas[0].db] != 2 {
+		t.Fatalf("unexpected distribution: %v", seen)
+	}
+
+	// 跳过摘除的从库
+	c.replicas[1].healthy.Store(false)
+	for range 4 {
+		if db := c.Replica(ctx); db == c.replicas[1].db || db == c.primary {
+			t.Fatal("picked ejected replica or primary")
+		}
+	}
+
+	// 强制读主库
+	if c.Replica(WithPrimary(ctx)) != c.primary || !IsPrimary(WithPrimary(ctx)) {
+		t.Fatal("expected primary with WithPrimary")
+	}
+
+	// 没有健康的从库时读主库
+	for _, r := range c.replicas {
+		r.healthy.Store(false)
+	}
+	if c.Replica(ctx) != c.primary {
+		t.Fatal("expected fallback to primary")
+	}
+}
+
+func TestClusterLeastConn(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCluster(t, LeastConn, 2)
+
+	conn, err := c.replicas[0].db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for range 3 {
+		if c.Replica(ctx) != c.replicas[1].db {
+			t.Fatal("expected replica with fewer connections in use")
+		}
+	}
+	c.replicas[1].healthy.Store(false)
+	if c.Replica(ctx) != c.replicas[0].db {
+		t.Fatal("expected remaining healthy replica")
+	}
+}
+
+func TestClusterCheckReplicas(t *testing.T) {
+	l := log.NewHelper(log.NewStdLogger(io.Discard))
+	o := newOptions(WithReplicaCheck(time.Second, 2))
+	c, servers := newTestCluster(t, RoundRobin, 2)
+
+	servers[0].down.Store(true)
+	c.checkReplicas(l, o)
+	if !c.replicas[0].healthy.Load() || c.replicas[0].fails != 1 {
+		t.Fatal("replica must not be ejected before the threshold")
+	}
+	c.checkReplicas(l, o)
+	if c.replicas[0].healthy.Load() || !c.replicas[1].healthy.Load() {
+		t.Fatal("expected only the failing replica to be ejected")
+	}
+
+	servers[0].down.Store(false)
+	c.checkReplicas(l, o)
+	if !c.replicas[0].healthy.Load() || c.replicas[0].fails != 0 {
+		t.Fatal("expected replica to be readmitted")
+	}
+
+	// 启动时连接不上的从库以摘除状态加入，检查成功后恢复
+	s := &flakyServer{fakeServer: newFakeServer()}
+	s.down.Store(true)
+	db := sql.OpenDB(s)
+	defer db.Close()
+	r := newReplica(l, db, "replica", o)
+	if r.healthy.Load() {
+		t.Fatal("expected unreachable replica to start ejected")
+	}
+	s.down.Store(false)
+	r.check(l, o)
+	if !r.healthy.Load() {
+		t.Fatal("expected replica to be readmitted")
+	}
+}
+
+func TestNewReplicasConcurrent(t *testing.T) {
+	l := log.NewHelper(log.NewStdLogger(io.Discard))
+	timeout := 300 * time.Millisecond
+	o := newOptions(WithReplicaCheck(timeout, 2))
+
+	var dbs []*sql.DB
+	for i := range 3 {
+		s := &flakyServer{fakeServer: newFakeServer(), hang: true}
+		// 两个从库不可达，一个正常
+		s.down.Store(i < 2)
+		db := sql.OpenDB(s)
+		defer db.Close()
+		dbs = append(dbs, db)
+	}
+
+	start := time.Now()
+	replicas := newReplicas(l, dbs, []string{"r1", "r2", "r3"}, o)
+	elapsed := time.Since(start)
+
+	if elapsed < timeout || elapsed > timeout*3/2 {
+		t.Fatalf("startup took %s, want about %s", elapsed, timeout)
+	}
+	if replicas[0].healthy.Load() || replicas[1].healthy.Load() || !replicas[2].healthy.Load() {
+		t.Fatal("expected only the unreachable replicas to start ejected")
+	}
+	if replicas[2].name != "r3" {
+		t.Fatalf("replica order changed: %s", replicas[2].name)
+	}
+}
@@ -0,0 +1,324 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/metricsx"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 链路追踪的 instrumentation 名称
+const tracerName = "github.com/lhlyu/kratos-easy/mysqlx"
+
+// queryDuration 每条语句的耗时，按语句类型区分
+//
+// db_instance 为实例名称，如 mysql@127.0.0.1:3306/dbname，不使用 instance 以免和 Prometheus 的抓取目标标签冲突。
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metricsx.Namespace,
+	Name:      "mysql_query_duration_seconds",
+	Help:      "MySQL statement latency in seconds by instance, operation and status.",
+	Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+}, []string{"db_instance", "operation", "status"})
+
+func init() {
+	metricsx.Registry().MustRegister(queryDuration)
+}
+
+/************************
+ * Instrument
+ ************************/
+
+// instrument 语句埋点：链路追踪、慢查询日志、耗时指标
+type instrument struct {
+	logger        log.Logger
+	name          string // 实例名称，如 mysql@127.0.0.1:3306/dbname
+	dbName        string
+	addr          string
+	tracing       bool
+	metrics       bool
+	slowThreshold time.Duration
+}
+
+// record 在语句执行完成后记录埋点，span 使用执行前后的时间戳补录
+func (in *instrument) record(ctx context.Context, start time.Time, query string, args []driver.NamedValue, res driver.Result, err error) {
+	end := time.Now()
+	elapsed := end.Sub(start)
+	op := operationName(query)
+
+	rows := int64(-1)
+	if err == nil && res != nil {
+		if n, e := res.RowsAffected(); e == nil {
+			rows = n
+		}
+	}
+
+	if in.tracing {
+		in.span(ctx, start, end, op, query, rows, err)
+	}
+
+	if in.metrics {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		queryDuration.WithLabelValues(in.name, op, status).Observe(elapsed.Seconds())
+	}
+
+	if in.slowThreshold > 0 && elapsed >= in.slowThreshold {
+		kvs := []any{
+			"msg", "slow query",
+			"db", in.name,
+			"query", query,
+			"args", redactArgs(args),
+			"latency", elapsed.String(),
+		}
+		if rows >= 0 {
+			kvs = append(kvs, "rows", rows)
+		}
+		if err != nil {
+			kvs = append(kvs, "error", err)
+		}
+		_ = log.WithContext(ctx, in.logger).Log(log.LevelWarn, kvs...)
+	}
+}
+
+// span 补录一个语句的 span
+func (in *instrument) span(ctx context.Context, start, end time.Time, op, query string, rows int64, err error) {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNameMySQL,
+		semconv.DBNamespace(in.dbName),
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(query),
+		semconv.ServerAddress(in.addr),
+	}
+	if rows >= 0 {
+		attrs = append(attrs, attribute.Int64("db.rows_affected", rows))
+	}
+
+	_, span := otel.Tracer(tracerName).Start(ctx, op+" "+in.dbName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// operationName 返回语句类型，如 SELECT、INSERT，用作 span 名称和指标标签
+func operationName(query string) string {
+	query = strings.TrimLeftFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '('
+	})
+	end := strings.IndexFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end >= 0 {
+		query = query[:end]
+	}
+	if query == "" {
+		return "OTHER"
+	}
+	return strings.ToUpper(query)
+}
+
+// redactArgs 隐藏参数值，只保留类型和长度
+func redactArgs(args []driver.NamedValue) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.Value.(type) {
+		case nil:
+			out[i] = "nil"
+		case string:
+			out[i] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			out[i] = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			out[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return out
+}
+
+/************************
+ * Driver
+ ************************/
+
+// connector 包装原始 connector，为每个连接加上埋点
+type connector struct {
+	base driver.Connector
+	in   *instrument
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{base: cn, in: c.in}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// conn 带埋点的连接
+type conn struct {
+	base driver.Conn
+	in   *instrument
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		st  driver.Stmt
+		err error
+	)
+	if p, ok := c.base.(driver.ConnPrepareContext); ok {
+		st, err = p.PrepareContext(ctx, query)
+	} else {
+		st, err = c.base.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{base: st, query: query, in: c.in}, nil
+}
+
+func (c *conn) Close() error {
+	return c.base.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.base.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.base.Begin()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.base.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	// ErrSkip 表示回退到预处理语句执行，由 stmt 记录
+	if err != driver.ErrSkip {
+		c.in.record(ctx, start, query, args, res, err)
+	}
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.base.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.in.record(ctx, start, query, args, nil, err)
+	}
+	return rows, err
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.base.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.base.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.base.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.base.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt 带埋点的预处理语句
+type stmt struct {
+	base  driver.Stmt
+	query string
+	in    *instrument
+}
+
+func (s *stmt) Close() error {
+	return s.base.Close()
+}
+
+func (s *stmt) NumInput() int {
+	return s.base.NumInput()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.base.Exec(args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.base.Query(args)
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.base.(driver.StmtExecContext)
+	if !ok {
+		return nil, fmt.Errorf("mysqlx: statement does not support ExecContext")
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, args)
+	s.in.record(ctx, start, s.query, args, res, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.base.(driver.StmtQueryContext)
+	if !ok {
+		return nil, fmt.Errorf("mysqlx: statement does not support QueryContext")
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, args)
+	s.in.record(ctx, start, s.query, args, nil, err)
+	return rows, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.base.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
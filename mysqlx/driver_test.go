@@ -0,0 +1,204 @@
+package mysqlx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// stubConnector 只支持预处理语句的 driver.Connector，direct 为 true 时连接同时支持直接执行
+type stubConnector struct {
+	direct bool
+	execs  []string
+}
+
+func (c *stubConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.direct {
+		return &stubDirectConn{stubConn{c: c}}, nil
+	}
+	return &stubConn{c: c}, nil
+}
+
+func (c *stubConnector) Driver() driver.Driver {
+	return nil
+}
+
+type stubConn struct {
+	c *stubConnector
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{c: c.c, query: query}, nil
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("tx not supported")
+}
+
+type stubDirectConn struct {
+	stubConn
+}
+
+func (c *stubDirectConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.c.execs = append(c.c.execs, "direct:"+query)
+	if strings.HasPrefix(query, "BAD") {
+		return nil, errors.New("syntax error")
+	}
+	return driver.RowsAffected(2), nil
+}
+
+func (c *stubDirectConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.c.execs = append(c.c.execs, "direct:"+query)
+	return &fakeRows{cols: []string{"n"}, data: [][]driver.Value{{int64(1)}}}, nil
+}
+
+type stubStmt struct {
+	c     *stubConnector
+	query string
+}
+
+func (s *stubStmt) Close() error {
+	return nil
+}
+
+func (s *stubStmt) NumInput() int {
+	return -1
+}
+
+func (s *stubStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("use ExecContext")
+}
+
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("use QueryContext")
+}
+
+func (s *stubStmt) ExecContext(context.Context, []driver.NamedValue) (driver.Result, error) {
+	s.c.execs = append(s.c.execs, "stmt:"+s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) QueryContext(context.Context, []driver.NamedValue) (driver.Rows, error) {
+	s.c.execs = append(s.c.execs, "stmt:"+s.query)
+	return &fakeRows{cols: []string{"n"}, data: [][]driver.Value{{int64(1)}}}, nil
+}
+
+// sampleCount 返回耗时指标的样本数
+func sampleCount(t *testing.T, instance, op, status string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := queryDuration.WithLabelValues(instance, op, status).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestDriverInstrument(t *testing.T) {
+	ctx := context.Background()
+	for _, direct := range []bool{true, false} {
+		name := "mysql@stub/direct"
+		if !direct {
+			name = "mysql@stub/stmt"
+		}
+		t.Run(name, func(t *testing.T) {
+			var logs bytes.Buffer
+			base := &stubConnector{direct: direct}
+			db := sql.OpenDB(&connector{base: base, in: &instrument{
+				logger:        log.NewStdLogger(&logs),
+				name:          name,
+				metrics:       true,
+				slowThreshold: time.Nanosecond,
+			}})
+			defer db.Close()
+
+			if _, err := db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", "secret", 1); err != nil {
+				t.Fatal(err)
+			}
+			var n int
+			if err := db.QueryRowContext(ctx, " (SELECT 1)").Scan(&n); err != nil || n != 1 {
+				t.Fatalf("query: %d %v", n, err)
+			}
+
+			st, err := db.PrepareContext(ctx, "DELETE FROM users WHERE id = ?")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.ExecContext(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			_ = st.Close()
+
+			prefix := "stmt:"
+			if direct {
+				prefix = "direct:"
+			}
+			if len(base.execs) != 3 || !strings.HasPrefix(base.execs[0], prefix) || base.execs[2] != "stmt:DELETE FROM users WHERE id = ?" {
+				t.Fatalf("unexpected executions: %v", base.execs)
+			}
+			for op, want := range map[string]uint64{"UPDATE": 1, "SELECT": 1, "DELETE": 1} {
+				if got := sampleCount(t, name, op, "ok"); got != want {
+					t.Fatalf("%s samples = %d, want %d", op, got, want)
+				}
+			}
+
+			// 慢查询日志中参数只保留类型和长度
+			out := logs.String()
+			if strings.Count(out, "slow query") != 3 || !strings.Contains(out, "string(6)") || strings.Contains(out, "secret") {
+				t.Fatalf("unexpected logs: %s", out)
+			}
+		})
+	}
+
+	// 直接执行失败时记录为 error
+	db := sql.OpenDB(&connector{base: &stubConnector{direct: true}, in: &instrument{
+		logger:  log.NewStdLogger(io.Discard),
+		name:    "mysql@stub/error",
+		metrics: true,
+	}})
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, "BAD STATEMENT"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := sampleCount(t, "mysql@stub/error", "BAD", "error"); got != 1 {
+		t.Fatalf("error samples = %d, want 1", got)
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := []driver.NamedValue{
+		{Value: nil}, {Value: "password"}, {Value: []byte("token")}, {Value: int64(42)}, {Value: time.Time{}},
+	}
+	got := strings.Join(redactArgs(args), ",")
+	if want := "nil,string(8),[]byte(5),int64,time.Time"; got != want {
+		t.Fatalf("redactArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	cases := map[string]string{
+		"select 1":              "SELECT",
+		"  (SELECT 1) UNION ..": "SELECT",
+		"INSERT INTO t":         "INSERT",
+		"":                      "OTHER",
+		"/* hint */ SELECT 1":   "OTHER",
+	}
+	for query, want := range cases {
+		if got := operationName(query); got != want {
+			t.Errorf("operationName(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
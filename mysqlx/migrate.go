@@ -0,0 +1,580 @@
+package mysqlx
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// migrationFile 匹配 <version>_<name>.up.sql 和 <version>_<name>.down.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_([^.]+)\.(up|down)\.sql$`)
+
+// ErrChecksumMismatch 已执行的迁移文件被修改
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrDirty 上一次迁移执行到一半失败，需要人工修复后再继续
+var ErrDirty = errors.New("migration is dirty")
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string // 可以为空，为空时该版本不能回滚
+	Checksum string // up 文件内容的 sha256
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Dirty     bool // 执行到一半失败
+	Modified  bool // 已执行后文件被修改
+	Missing   bool // 已执行但找不到对应文件
+}
+
+/************************
+ * Option
+ ************************/
+
+// migrateOptions 迁移配置
+type migrateOptions struct {
+	table       string
+	lockName    string
+	lockTimeout time.Duration
+	logger      log.Logger
+}
+
+// MigrateOption 表示迁移配置项的函数式选项。
+type MigrateOption func(*migrateOptions)
+
+// WithMigrationTable 设置记录已执行版本的表名，默认 schema_migrations。
+func WithMigrationTable(table string) MigrateOption {
+	return func(o *migrateOptions) {
+		if table != "" {
+			o.table = table
+		}
+	}
+}
+
+// WithMigrationLock 设置 GET_LOCK 使用的锁名和最长等待时间，默认 <table>、60 秒。
+func WithMigrationLock(name string, timeout time.Duration) MigrateOption {
+	return func(o *migrateOptions) {
+		if name != "" {
+			o.lockName = name
+		}
+		if timeout > 0 {
+			o.lockTimeout = timeout
+		}
+	}
+}
+
+// WithMigrationLogger 设置迁移过程的日志器，默认 log.DefaultLogger。
+func WithMigrationLogger(logger log.Logger) MigrateOption {
+	return func(o *migrateOptions) {
+		o.logger = logger
+	}
+}
+
+/************************
+ * Migrator
+ ************************/
+
+// Migrator 数据库迁移执行器
+//
+// 迁移文件命名为 <version>_<name>.up.sql、<version>_<name>.down.sql，
+// 可以来自 embed.FS 或 os.DirFS。执行期间持有 GET_LOCK，多个实例同时启动时只有一个会执行。
+type Migrator struct {
+	db   *sql.DB
+	fsys fs.FS
+	opts *migrateOptions
+}
+
+// NewMigrator 新建迁移执行器，fsys 的根目录即迁移文件所在目录
+func NewMigrator(db *sql.DB, fsys fs.FS, opts ...MigrateOption) *Migrator {
+	o := &migrateOptions{
+		table:       "schema_migrations",
+		lockTimeout: 60 * time.Second,
+		logger:      log.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.lockName == "" {
+		o.lockName = o.table
+	}
+	return &Migrator{db: db, fsys: fsys, opts: o}
+}
+
+// Migrations 返回按版本排序的所有迁移
+func (m *Migrator) Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(m.fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", e.Name(), err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		} else if mg.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, mg.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			mg.Up = string(body)
+			mg.Checksum = checksum(body)
+		} else {
+			mg.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mg.Version, mg.Name)
+		}
+		out = append(out, *mg)
+	}
+	slices.SortFunc(out, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return out, nil
+}
+
+// Status 返回所有迁移的执行状态，包括已执行但文件已删除的版本
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	return m.status(ctx, conn)
+}
+
+// Up 按版本顺序执行未执行的迁移，steps <= 0 表示全部执行，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		list, err := m.checkedStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, st := range list {
+			if st.Applied || st.Missing {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if err := m.apply(ctx, conn, st.Migration); err != nil {
+				return err
+			}
+			done = append(done, st.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本倒序回滚已执行的迁移，steps <= 0 表示全部回滚，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		list, err := m.checkedStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, st := range slices.Backward(list) {
+			if !st.Applied {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if st.Missing {
+				return fmt.Errorf("migration %d is applied but its file is missing", st.Version)
+			}
+			if err := m.revert(ctx, conn, st.Migration); err != nil {
+				return err
+			}
+			done = append(done, st.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Force 清除 version 的 dirty 标记，用于人工修复失败的迁移后继续执行
+//
+// applied 为 true 时把该版本记录为已执行，否则删除记录，视为未执行。
+// Force 不执行任何迁移语句，调用前需要确认数据库结构与记录一致。
+func (m *Migrator) Force(ctx context.Context, version uint64, applied bool) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		list, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(list, func(st MigrationStatus) bool { return st.Version == version })
+		if i < 0 {
+			return fmt.Errorf("migration %d not found", version)
+		}
+		st := list[i]
+
+		switch {
+		case applied && !st.Applied:
+			_, err = conn.ExecContext(ctx, "INSERT INTO "+m.quotedTable()+" (version, name, checksum, dirty) VALUES (?, ?, ?, 0)",
+				st.Version, st.Name, st.Checksum)
+		case applied:
+			_, err = conn.ExecContext(ctx, "UPDATE "+m.quotedTable()+" SET dirty = 0 WHERE version = ?", st.Version)
+		case st.Applied:
+			_, err = conn.ExecContext(ctx, "DELETE FROM "+m.quotedTable()+" WHERE version = ?", st.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("record migration %d: %w", st.Version, err)
+		}
+
+		log.NewHelper(m.opts.logger).Warnw("msg", "migration forced", "version", st.Version, "name", st.Name, "applied", applied, "dirty", st.Dirty)
+		return nil
+	})
+}
+
+// withLock 在同一个连接上持有 GET_LOCK 执行 fn
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got sql.NullInt64
+	timeout := int64(m.opts.lockTimeout / time.Second)
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", m.opts.lockName, timeout).Scan(&got); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if got.Int64 != 1 {
+		return fmt.Errorf("acquire migration lock %q: timeout after %s", m.opts.lockName, m.opts.lockTimeout)
+	}
+	defer func() {
+		// 使用独立的 context，确保 ctx 取消后也能释放锁
+		_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", m.opts.lockName)
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureTable 创建版本表
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.quotedTable()+` (
+  version BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  checksum CHAR(64) NOT NULL,
+  dirty TINYINT(1) NOT NULL DEFAULT 0,
+  applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`)
+	if err != nil {
+		return fmt.Errorf("create migration table: %w", err)
+	}
+	return nil
+}
+
+// status 合并文件和版本表，得到每个迁移的状态
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	files, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, dirty, UNIX_TIMESTAMP(applied_at) FROM "+m.quotedTable()+" ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]MigrationStatus)
+	for rows.Next() {
+		var (
+			st MigrationStatus
+			at int64
+		)
+		if err := rows.Scan(&st.Version, &st.Name, &st.Checksum, &st.Dirty, &at); err != nil {
+			return nil, err
+		}
+		st.Applied = true
+		st.AppliedAt = time.Unix(at, 0)
+		applied[st.Version] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]MigrationStatus, 0, len(files)+len(applied))
+	for _, f := range files {
+		st := MigrationStatus{Migration: f}
+		if a, ok := applied[f.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.AppliedAt
+			st.Dirty = a.Dirty
+			st.Modified = a.Checksum != f.Checksum
+			delete(applied, f.Version)
+		}
+		out = append(out, st)
+	}
+	for _, a := range applied {
+		a.Missing = true
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b MigrationStatus) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return out, nil
+}
+
+// checkedStatus 返回迁移状态，存在未完成或被修改的迁移时返回错误
+func (m *Migrator) checkedStatus(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	list, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range list {
+		if st.Dirty {
+			return nil, fmt.Errorf("%w: version %d", ErrDirty, st.Version)
+		}
+		if st.Modified {
+			return nil, fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, st.Version, st.Name)
+		}
+	}
+	return list, nil
+}
+
+// apply 执行一个迁移，执行前先记录为 dirty，全部语句成功后清除
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	l := log.NewHelper(m.opts.logger)
+	start := time.Now()
+
+	if _, err := conn.ExecContext(ctx, "INSERT INTO "+m.quotedTable()+" (version, name, checksum, dirty) VALUES (?, ?, ?, 1)",
+		mg.Version, mg.Name, mg.Checksum); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	if err := execScript(ctx, conn, mg.Up); err != nil {
+		return fmt.Errorf("apply migration %d_%s: %w", mg.Version, mg.Name, err)
+	}
+	if _, err := conn.ExecContext(ctx, "UPDATE "+m.quotedTable()+" SET dirty = 0 WHERE version = ?", mg.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+
+	l.Infow("msg", "migration applied", "version", mg.Version, "name", mg.Name, "latency", time.Since(start).String())
+	return nil
+}
+
+// revert 回滚一个迁移，执行前先标记为 dirty，全部语句成功后删除记录
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mg Migration) error {
+	l := log.NewHelper(m.opts.logger)
+	start := time.Now()
+
+	if strings.TrimSpace(mg.Down) == "" {
+		return fmt.Errorf("migration %d_%s has no down file", mg.Version, mg.Name)
+	}
+	if _, err := conn.ExecContext(ctx, "UPDATE "+m.quotedTable()+" SET dirty = 1 WHERE version = ?", mg.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+	if err := execScript(ctx, conn, mg.Down); err != nil {
+		return fmt.Errorf("revert migration %d_%s: %w", mg.Version, mg.Name, err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM "+m.quotedTable()+" WHERE version = ?", mg.Version); err != nil {
+		return fmt.Errorf("record migration %d: %w", mg.Version, err)
+	}
+
+	l.Infow("msg", "migration reverted", "version", mg.Version, "name", mg.Name, "latency", time.Since(start).String())
+	return nil
+}
+
+// quotedTable 返回带反引号的表名
+func (m *Migrator) quotedTable() string {
+	return "`" + strings.ReplaceAll(m.opts.table, "`", "``") + "`"
+}
+
+/************************
+ * Create
+ ************************/
+
+// CreateMigration 在 dir 下创建一对以当前 UTC 时间为版本号的空迁移文件，返回文件路径
+func CreateMigration(dir, name string) (up, down string, err error) {
+	name = sanitizeName(name)
+	if name == "" {
+		return "", "", errors.New("migration name is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	base := filepath.Join(dir, version+"_"+name)
+	up, down = base+".up.sql", base+".down.sql"
+
+	for _, path := range []string{up, down} {
+		if _, err := os.Stat(path); err == nil {
+			return "", "", fmt.Errorf("file already exists: %s", path)
+		}
+	}
+	if err := os.WriteFile(up, []byte("-- "+name+" up\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+name+" down\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// sanitizeName 把迁移名称转换为小写字母、数字和下划线
+func sanitizeName(name string) string {
+	b := &strings.Builder{}
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+/************************
+ * Helper
+ ************************/
+
+// execScript 逐条执行脚本中的语句，不依赖 multiStatements 参数
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements 按分号拆分 SQL 脚本，忽略引号和注释中的分号，并去掉只有注释的语句
+func splitStatements(script string) []string {
+	var (
+		out     []string
+		cur     strings.Builder
+		hasCode bool
+	)
+	flush := func() {
+		if hasCode {
+			out = append(out, strings.TrimSpace(cur.String()))
+		}
+		cur.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// 引号内的内容原样保留，支持反斜杠转义和重复引号
+			j := i + 1
+			for j < len(script) {
+				if script[j] == '\\' && c != '`' {
+					j += 2
+					continue
+				}
+				if script[j] == c {
+					if j+1 < len(script) && script[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			end := min(j+1, len(script))
+			cur.WriteString(script[i:end])
+			hasCode = true
+			i = end - 1
+		case isLineComment(script[i:]):
+			// 单行注释
+			j := strings.IndexByte(script[i:], '\n')
+			if j < 0 {
+				i = len(script)
+			} else {
+				i += j
+				cur.WriteByte('\n')
+			}
+		case strings.HasPrefix(script[i:], "/*") && !strings.HasPrefix(script[i:], "/*!"):
+			j := strings.Index(script[i+2:], "*/")
+			if j < 0 {
+				i = len(script)
+			} else {
+				i += j + 3
+			}
+		case c == ';':
+			flush()
+		default:
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				hasCode = true
+			}
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return out
+}
+
+// checksum 返回内容的 sha256
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// isLineComment 判断是否为单行注释：# 或 -- 后跟空白
+func isLineComment(s string) bool {
+	if strings.HasPrefix(s, "#") {
+		return true
+	}
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\r' || s[2] == '\n'
+}
@@ -0,0 +1,407 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+/************************
+ * Stand-in
+ ************************/
+
+// fakeServer 进程内的 MySQL 替身，只支持迁移和事务用到的语句
+type fakeServer struct {
+	mu     sync.Mutex
+	locks  map[string]*fakeConn
+	rows   map[int64][]driver.Value // version -> version, name, checksum, dirty, applied_at
+	tables map[string]bool
+	log    []string // 事务和 UPDATE 语句的执行记录
+}
+
+// statements 返回执行记录并清空
+func (s *fakeServer) statements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log := s.log
+	s.log = nil
+	return log
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		locks:  make(map[string]*fakeConn),
+		rows:   make(map[int64][]driver.Value),
+		tables: make(map[string]bool),
+	}
+}
+
+func (s *fakeServer) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{s: s}, nil
+}
+
+func (s *fakeServer) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	s *fakeServer
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.s.record("BEGIN")
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.s.record("COMMIT")
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.s.record("ROLLBACK")
+	return nil
+}
+
+func (s *fakeServer) record(stmt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, stmt)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	arg := func(i int) driver.Value { return args[i].Value }
+	fields := strings.Fields(query)
+
+	switch {
+	case strings.HasPrefix(query, "SELECT RELEASE_LOCK"):
+		if s.locks[arg(0).(string)] == c {
+			delete(s.locks, arg(0).(string))
+		}
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS `schema_migrations`"):
+	case strings.HasPrefix(query, "INSERT INTO `schema_migrations`"):
+		v := arg(0).(int64)
+		if _, ok := s.rows[v]; ok {
+			return nil, fmt.Errorf("duplicate entry %d", v)
+		}
+		dirty := int64(1)
+		if strings.HasSuffix(query, ", 0)") {
+			dirty = 0
+		}
+		s.rows[v] = []driver.Value{v, arg(1), arg(2), dirty, time.Now().Unix()}
+	case strings.HasPrefix(query, "UPDATE `schema_migrations` SET dirty = "):
+		s.rows[arg(0).(int64)][3] = map[string]int64{"0": 0, "1": 1}[fields[5]]
+	case strings.HasPrefix(query, "DELETE FROM `schema_migrations`"):
+		delete(s.rows, arg(0).(int64))
+	case strings.HasPrefix(query, "CREATE TABLE"):
+		if s.tables[fields[2]] {
+			return nil, fmt.Errorf("table %s already exists", fields[2])
+		}
+		s.tables[fields[2]] = true
+	case strings.HasPrefix(query, "DROP TABLE"):
+		if !s.tables[fields[2]] {
+			return nil, fmt.Errorf("unknown table %s", fields[2])
+		}
+		delete(s.tables, fields[2])
+	case strings.HasPrefix(query, "SAVEPOINT"), strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT"),
+		strings.HasPrefix(query, "RELEASE SAVEPOINT"), strings.HasPrefix(query, "UPDATE accounts"):
+		s.log = append(s.log, query)
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s := c.s
+	switch {
+	case strings.HasPrefix(query, "SELECT GET_LOCK"):
+		name := args[0].Value.(string)
+		deadline := time.Now().Add(time.Duration(args[1].Value.(int64)) * time.Second)
+		for {
+			s.mu.Lock()
+			if owner, ok := s.locks[name]; !ok || owner == c {
+				s.locks[name] = c
+				s.mu.Unlock()
+				return &fakeRows{cols: []string{"lock"}, data: [][]driver.Value{{int64(1)}}}, nil
+			}
+			s.mu.Unlock()
+			if time.Now().After(deadline) {
+				return &fakeRows{cols: []string{"lock"}, data: [][]driver.Value{{int64(0)}}}, nil
+			}
+			time.Sleep(time.Millisecond)
+		}
+	case strings.HasPrefix(query, "SELECT version, name, checksum, dirty"):
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		rows := &fakeRows{cols: []string{"version", "name", "checksum", "dirty", "applied_at"}}
+		for _, v := range slices.Sorted(maps.Keys(s.rows)) {
+			rows.data = append(rows.data, slices.Clone(s.rows[v]))
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported query: %s", query)
+	}
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+	i    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.cols
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.i])
+	r.i++
+	return nil
+}
+
+/************************
+ * Tests
+ ************************/
+
+func newTestMigrator(s *fakeServer, fsys fstest.MapFS) *Migrator {
+	return NewMigrator(sql.OpenDB(s), fsys,
+		WithMigrationLogger(log.NewStdLogger(io.Discard)),
+		WithMigrationLock("test", time.Second),
+	)
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"1_users.up.sql":     {Data: []byte("-- users; table\nCREATE TABLE users (id INT);\n")},
+		"1_users.down.sql":   {Data: []byte("DROP TABLE users;")},
+		"2_orders.up.sql":    {Data: []byte("CREATE TABLE orders (note VARCHAR(8) DEFAULT ';');\nCREATE TABLE items (id INT);")},
+		"2_orders.down.sql":  {Data: []byte("DROP TABLE items; DROP TABLE orders;")},
+		"10_audits.up.sql":   {Data: []byte("/* audit; log */ CREATE TABLE audits (id INT)")},
+		"10_audits.down.sql": {Data: []byte("DROP TABLE audits")},
+		"README.md":          {Data: []byte("ignored")},
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer()
+	m := newTestMigrator(s, testMigrations())
+
+	done, err := m.Up(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done[0].Version != 1 || done[1].Version != 2 {
+		t.Fatalf("unexpected applied migrations: %+v", done)
+	}
+
+	done, err = m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != 10 {
+		t.Fatalf("unexpected applied migrations: %+v", done)
+	}
+	for _, name := range []string{"users", "orders", "items", "audits"} {
+		if !s.tables[name] {
+			t.Fatalf("table %s not created", name)
+		}
+	}
+
+	list, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range list {
+		if !st.Applied || st.Dirty || st.Modified || st.Missing {
+			t.Fatalf("unexpected status: %+v", st)
+		}
+	}
+
+	done, err = m.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done[0].Version != 10 || done[1].Version != 2 {
+		t.Fatalf("unexpected reverted migrations: %+v", done)
+	}
+	if s.tables["orders"] || s.tables["audits"] || !s.tables["users"] {
+		t.Fatalf("unexpected tables after down: %v", s.tables)
+	}
+	if len(s.rows) != 1 {
+		t.Fatalf("expected 1 applied version, got %d", len(s.rows))
+	}
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer()
+	fsys := testMigrations()
+
+	if _, err := newTestMigrator(s, fsys).Up(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["1_users.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE users (id BIGINT);")}
+	if _, err := newTestMigrator(s, fsys).Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+
+	list, err := newTestMigrator(s, fsys).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !list[0].Modified {
+		t.Fatalf("expected version 1 to be modified: %+v", list[0])
+	}
+}
+
+func TestMigrateDirty(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer()
+	fsys := fstest.MapFS{
+		"1_bad.up.sql": {Data: []byte("CREATE TABLE a (id INT); BROKEN STATEMENT;")},
+		"2_ok.up.sql":  {Data: []byte("CREATE TABLE b (id INT);")},
+	}
+	m := newTestMigrator(s, fsys)
+
+	if _, err := m.Up(ctx, 0); err == nil {
+		t.Fatal("expected error from broken migration")
+	}
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrDirty) {
+		t.Fatalf("expected ErrDirty, got %v", err)
+	}
+	if s.tables["b"] {
+		t.Fatal("migration after a dirty version must not run")
+	}
+}
+
+func TestMigrateForce(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer()
+	fsys := fstest.MapFS{
+		"1_bad.up.sql": {Data: []byte("CREATE TABLE a (id INT); BROKEN STATEMENT;")},
+		"2_ok.up.sql":  {Data: []byte("CREATE TABLE b (id INT);")},
+	}
+	m := newTestMigrator(s, fsys)
+	if _, err := m.Up(ctx, 0); err == nil {
+		t.Fatal("expected error from broken migration")
+	}
+
+	if err := m.Force(ctx, 3, true); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+
+	// 人工回滚后记录为未执行，修复文件后可以重新执行
+	if err := m.Force(ctx, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	list, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Applied || list[0].Dirty {
+		t.Fatalf("version 1 should be pending: %+v", list[0])
+	}
+
+	// 人工补完后记录为已执行，后续版本继续执行
+	if _, err := m.Up(ctx, 0); err == nil {
+		t.Fatal("expected error from broken migration")
+	}
+	if err := m.Force(ctx, 1, true); err != nil {
+		t.Fatal(err)
+	}
+	done, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != 2 || !s.tables["b"] {
+		t.Fatalf("expected version 2 to be applied, got %+v", done)
+	}
+
+	// 未执行的版本也可以直接记录为已执行
+	m = newTestMigrator(s, fstest.MapFS{"3_seed.up.sql": {Data: []byte("BROKEN STATEMENT;")}})
+	if err := m.Force(ctx, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	if list, err = m.Status(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := list[len(list)-1]; st.Version != 3 || !st.Applied || st.Dirty {
+		t.Fatalf("version 3 should be applied: %+v", st)
+	}
+}
+
+func TestMigrateConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer()
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := newTestMigrator(s, testMigrations()).Up(ctx, 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			total += len(done)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if total != 3 {
+		t.Fatalf("expected each migration to be applied once, got %d", total)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := "-- comment; here\nINSERT INTO t VALUES ('a;b', \"c\\\";d\");\n# another; comment\n/* block; */UPDATE `x;y` SET a = 1;\n--\n;  ;"
+	got := splitStatements(script)
+	want := []string{
+		"INSERT INTO t VALUES ('a;b', \"c\\\";d\")",
+		"UPDATE `x;y` SET a = 1",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("splitStatements() = %q, want %q", got, want)
+	}
+}
@@ -6,58 +6,123 @@ import (
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lhlyu/kratos-easy/healthx"
+	"github.com/lhlyu/kratos-easy/metricsx"
 )
 
 // NewClient 新建 mysql 客户端
 //
 // source 格式示例：username:password@tcp(domain.com:3306)/dbname?parseTime=True&loc=Local
+//
+// 每条语句默认会生成链路追踪 span、记录耗时指标，超过慢查询阈值时输出日志。
 func NewClient(logger log.Logger, source string, opts ...Option) (*sql.DB, func(), error) {
 	l := log.NewHelper(logger)
-	db, err := sql.Open("mysql", source)
+
+	o := newOptions(opts...)
+
+	db, name, err := openDB(logger, source, o)
 	if err != nil {
-		l.Errorw(
-			"open database failed",
-			"error", err,
-		)
 		return nil, nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	l.Infow("database connected")
+
+	// 注册到就绪检查
+	unregisterHealth := func() {}
+	if o.enableHealth {
+		unregisterHealth = healthx.Register(name, db.PingContext)
+	}
+
+	// 注册连接池指标
+	unregisterMetrics := func() {}
+	if o.enableMetrics {
+		unregisterMetrics = metricsx.RegisterDB(name, db)
+	}
+
+	cleanup := func() {
+		unregisterHealth()
+		unregisterMetrics()
+		if err := db.Close(); err != nil {
+			l.Errorw("close database failed", "error", err)
+		}
+	}
+
+	return db, cleanup, nil
+}
+
+// openDB 打开带埋点的连接池并检查连接，返回实例名称
+func openDB(logger log.Logger, source string, o *options) (*sql.DB, string, error) {
+	l := log.NewHelper(logger)
+
+	db, name, err := newDB(logger, source, o)
+	if err != nil {
+		return nil, "", err
+	}
 
-	if err := db.PingContext(ctx); err != nil {
+	if err := pingDB(db, 30*time.Second); err != nil {
 		_ = db.Close()
 		l.Errorw(
 			"ping database failed",
 			"error", err,
 		)
-		return nil, nil, err
+		return nil, "", err
 	}
+	return db, name, nil
+}
 
-	o := &options{
-		maxOpen:      20,
-		maxIdleCount: 10,
-		maxLifetime:  30 * time.Minute,
-		maxIdleTime:  10 * time.Minute,
+// newDB 创建带埋点的连接池并应用连接池配置，不会建立连接，返回实例名称
+func newDB(logger log.Logger, source string, o *options) (*sql.DB, string, error) {
+	l := log.NewHelper(logger)
+
+	cfg, err := mysql.ParseDSN(source)
+	if err != nil {
+		l.Errorw(
+			"open database failed",
+			"error", err,
+		)
+		return nil, "", err
 	}
 
-	for _, opt := range opts {
-		opt(o)
+	base, err := mysql.NewConnector(cfg)
+	if err != nil {
+		l.Errorw(
+			"open database failed",
+			"error", err,
+		)
+		return nil, "", err
 	}
 
+	name := instanceName(cfg)
+	db := sql.OpenDB(&connector{
+		base: base,
+		in: &instrument{
+			logger:        logger,
+			name:          name,
+			dbName:        cfg.DBName,
+			addr:          cfg.Addr,
+			tracing:       o.enableTracing,
+			metrics:       o.enableMetrics,
+			slowThreshold: o.slowThreshold,
+		},
+	})
+
 	db.SetConnMaxLifetime(o.maxLifetime)
 	db.SetConnMaxIdleTime(o.maxIdleTime)
 	db.SetMaxOpenConns(o.maxOpen)
 	db.SetMaxIdleConns(o.maxIdleCount)
 
-	l.Infow("database connected")
+	return db, name, nil
+}
 
-	cleanup := func() {
-		if err := db.Close(); err != nil {
-			l.Errorw("close database failed", "error", err)
-		}
-	}
+// pingDB 在超时时间内检查连接
+func pingDB(db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return db.PingContext(ctx)
+}
 
-	return db, cleanup, nil
+// instanceName 返回用于健康检查和指标的实例名称，不包含账号密码
+func instanceName(cfg *mysql.Config) string {
+	return "mysql@" + cfg.Addr + "/" + cfg.DBName
 }
@@ -15,11 +15,53 @@ type options struct {
 
 	// maxIdleTime 表示单个连接允许的最大空闲时间。
 	maxIdleTime time.Duration
+
+	// enableHealth 表示是否注册到 healthx 就绪检查。
+	enableHealth bool
+
+	// enableMetrics 表示是否注册连接池和语句耗时指标到 metricsx。
+	enableMetrics bool
+
+	// enableTracing 表示是否为每条语句生成链路追踪 span。
+	enableTracing bool
+
+	// slowThreshold 表示慢查询阈值，<= 0 表示不输出慢查询日志。
+	slowThreshold time.Duration
+
+	// balance 表示集群读请求在从库间的负载均衡策略。
+	balance Balance
+
+	// checkInterval 表示集群从库健康检查的间隔。
+	checkInterval time.Duration
+
+	// ejectAfter 表示从库连续检查失败多少次后摘除。
+	ejectAfter int
 }
 
 // Option 表示 mysql 配置项的函数式选项。
 type Option func(*options)
 
+// newOptions 创建并初始化 mysql 配置。
+func newOptions(opts ...Option) *options {
+	o := &options{
+		maxOpen:       20,
+		maxIdleCount:  10,
+		maxLifetime:   30 * time.Minute,
+		maxIdleTime:   10 * time.Minute,
+		enableHealth:  true,
+		enableMetrics: true,
+		enableTracing: true,
+		slowThreshold: 200 * time.Millisecond,
+		balance:       RoundRobin,
+		checkInterval: 5 * time.Second,
+		ejectAfter:    3,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 // WithMaxLifetime 设置单个连接的最大存活时间。
 //
 // 如果 d <= 0，则表示不限制连接的存活时间。
@@ -64,6 +106,57 @@ func WithMaxIdleConns(n int) Option {
 	}
 }
 
+// WithDisableHealthCheck 禁止把连接注册到 healthx 就绪检查。
+func WithDisableHealthCheck() Option {
+	return func(o *options) {
+		o.enableHealth = false
+	}
+}
+
+// WithDisableMetrics 禁止把连接池和语句耗时指标注册到 metricsx。
+func WithDisableMetrics() Option {
+	return func(o *options) {
+		o.enableMetrics = false
+	}
+}
+
+// WithDisableTracing 禁止为每条语句生成链路追踪 span。
+func WithDisableTracing() Option {
+	return func(o *options) {
+		o.enableTracing = false
+	}
+}
+
+// WithSlowThreshold 设置慢查询阈值，超过阈值的语句会以 Warn 等级输出日志，参数值会被隐藏。
+//
+// 默认 200ms，如果 d <= 0，则表示不输出慢查询日志。
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = d
+	}
+}
+
+// WithBalance 设置集群读请求在从库间的负载均衡策略，默认 RoundRobin。
+func WithBalance(b Balance) Option {
+	return func(o *options) {
+		o.balance = b
+	}
+}
+
+// WithReplicaCheck 设置集群从库健康检查的间隔和摘除阈值。
+//
+// 从库连续失败 failures 次后摘除，之后检查成功一次即恢复。默认每 5 秒检查一次，连续失败 3 次摘除。
+func WithReplicaCheck(interval time.Duration, failures int) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.checkInterval = interval
+		}
+		if failures > 0 {
+			o.ejectAfter = failures
+		}
+	}
+}
+
 // WithCustomConfig 添加更多预设
 func WithCustomConfig(maxOpen, maxIdle int, lifetime, idleTime time.Duration) Option {
 	return func(o *options) {
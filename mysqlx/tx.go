@@ -0,0 +1,211 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lhlyu/kratos-easy/utilx"
+)
+
+// 可重试的 MySQL 错误码
+const (
+	errDeadlock        = 1213 // ER_LOCK_DEADLOCK
+	errLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT
+)
+
+// DBTX *sql.DB 和 *sql.Tx 共同的查询方法
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+/************************
+ * Option
+ ************************/
+
+// txOptions 事务配置
+type txOptions struct {
+	// maxAttempts 表示遇到死锁或锁等待超时时最多执行的次数，包含第一次。
+	maxAttempts int
+
+	// retryDelay 表示每次重试之间的等待时间。
+	retryDelay time.Duration
+
+	// txOpts 表示开启事务时使用的隔离级别和只读设置。
+	txOpts *sql.TxOptions
+}
+
+// TxOption 表示事务配置项的函数式选项。
+type TxOption func(*txOptions)
+
+// WithTxRetry 设置遇到死锁（1213）或锁等待超时（1205）时的重试策略。
+//
+// attempts 包含第一次执行，attempts <= 1 表示不重试。
+func WithTxRetry(attempts int, delay time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.maxAttempts = max(attempts, 1)
+		o.retryDelay = delay
+	}
+}
+
+// WithTxIsolation 设置事务的隔离级别。
+func WithTxIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.txOpts.Isolation = level
+	}
+}
+
+// WithTxReadOnly 设置为只读事务。
+func WithTxReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.txOpts.ReadOnly = true
+	}
+}
+
+/************************
+ * Transactor
+ ************************/
+
+// txKey 在 context 中保存事务的 key，按 *sql.DB 区分，避免不同库的事务串用
+type txKey struct {
+	db *sql.DB
+}
+
+// txState context 中保存的事务状态
+type txState struct {
+	tx    *sql.Tx
+	depth int // 嵌套层数，0 表示最外层事务
+}
+
+// Transactor 事务管理器
+//
+// 事务保存在 context 中，仓储层通过 Conn(ctx) 获取查询对象即可自动加入事务。
+type Transactor struct {
+	db   *sql.DB
+	opts *txOptions
+}
+
+// NewTransactor 新建事务管理器，默认在死锁或锁等待超时时最多执行 3 次，间隔 50ms
+func NewTransactor(db *sql.DB, opts ...TxOption) *Transactor {
+	o := &txOptions{
+		maxAttempts: 3,
+		retryDelay:  50 * time.Millisecond,
+		txOpts:      &sql.TxOptions{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Transactor{db: db, opts: o}
+}
+
+// Conn 返回 ctx 中的事务，不在事务中时返回 *sql.DB
+func (t *Transactor) Conn(ctx context.Context) DBTX {
+	if tx, ok := t.TxFromContext(ctx); ok {
+		return tx
+	}
+	return t.db
+}
+
+// TxFromContext 返回 ctx 中属于该数据库的事务
+func (t *Transactor) TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	st, ok := ctx.Value(txKey{db: t.db}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return st.tx, true
+}
+
+// InTx 在事务中执行 fn
+//
+//   - fn 返回错误或 panic 时回滚，panic 会在回滚后继续抛出
+//   - ctx 中已有事务时使用 SAVEPOINT，fn 失败只回滚到该保存点
+//   - 最外层事务遇到死锁或锁等待超时时整体重试，fn 需要可重复执行
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if st, ok := ctx.Value(txKey{db: t.db}).(*txState); ok {
+		return t.savepoint(ctx, st, fn)
+	}
+
+	if t.opts.maxAttempts <= 1 {
+		return t.run(ctx, fn)
+	}
+
+	var result error
+	err := utilx.Retry(ctx, t.opts.maxAttempts, t.opts.retryDelay, func() error {
+		result = t.run(ctx, fn)
+		if IsRetryable(result) {
+			return result
+		}
+		// 成功或不可重试的错误，结束重试
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// run 开启一个新事务并执行 fn
+func (t *Transactor) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := t.db.BeginTx(ctx, t.opts.txOpts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	txCtx := context.WithValue(ctx, txKey{db: t.db}, &txState{tx: tx})
+	if err := fn(txCtx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// savepoint 在已有事务中通过保存点执行 fn
+func (t *Transactor) savepoint(ctx context.Context, st *txState, fn func(ctx context.Context) error) (err error) {
+	next := &txState{tx: st.tx, depth: st.depth + 1}
+	name := "sp_" + strconv.Itoa(next.depth)
+
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{db: t.db}, next)); err != nil {
+		if _, rbErr := st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	_, err = st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// IsRetryable 判断错误是否为死锁（1213）或锁等待超时（1205）
+func IsRetryable(err error) bool {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return false
+	}
+	return me.Number == errDeadlock || me.Number == errLockWaitTimeout
+}
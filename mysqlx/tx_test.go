@@ -0,0 +1,171 @@
+package mysqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func newTestTransactor(opts ...TxOption) (*Transactor, *fakeServer) {
+	s := newFakeServer()
+	return NewTransactor(sql.OpenDB(s), append([]TxOption{WithTxRetry(3, 0)}, opts...)...), s
+}
+
+func update(ctx context.Context, t *Transactor, label string) error {
+	_, err := t.Conn(ctx).ExecContext(ctx, "UPDATE accounts "+label)
+	return err
+}
+
+func TestInTxCommitAndRollback(t *testing.T) {
+	ctx := context.Background()
+	tx, s := newTestTransactor()
+
+	if err := tx.InTx(ctx, func(ctx context.Context) error {
+		if _, ok := tx.TxFromContext(ctx); !ok {
+			t.Fatal("expected transaction in context")
+		}
+		return update(ctx, tx, "a")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.statements(); !slices.Equal(got, []string{"BEGIN", "UPDATE accounts a", "COMMIT"}) {
+		t.Fatalf("commit: %v", got)
+	}
+
+	boom := errors.New("boom")
+	if err := tx.InTx(ctx, func(ctx context.Context) error {
+		_ = update(ctx, tx, "b")
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+	if got := s.statements(); !slices.Equal(got, []string{"BEGIN", "UPDATE accounts b", "ROLLBACK"}) {
+		t.Fatalf("rollback: %v", got)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "panic" {
+				t.Fatalf("expected panic to be re-raised, got %v", r)
+			}
+		}()
+		_ = tx.InTx(ctx, func(context.Context) error { panic("panic") })
+	}()
+	if got := s.statements(); !slices.Equal(got, []string{"BEGIN", "ROLLBACK"}) {
+		t.Fatalf("panic: %v", got)
+	}
+}
+
+func TestInTxSavepoint(t *testing.T) {
+	ctx := context.Background()
+	tx, s := newTestTransactor()
+	boom := errors.New("boom")
+
+	if err := tx.InTx(ctx, func(ctx context.Context) error {
+		_ = update(ctx, tx, "outer")
+		err := tx.InTx(ctx, func(ctx context.Context) error {
+			_ = update(ctx, tx, "inner")
+			return tx.InTx(ctx, func(ctx context.Context) error {
+				_ = update(ctx, tx, "innermost")
+				return boom
+			})
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("expected nested error, got %v", err)
+		}
+		// 只回滚嵌套部分，外层事务继续提交
+		return tx.InTx(ctx, func(ctx context.Context) error {
+			return update(ctx, tx, "after")
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"UPDATE accounts outer",
+		"SAVEPOINT sp_1",
+		"UPDATE accounts inner",
+		"SAVEPOINT sp_2",
+		"UPDATE accounts innermost",
+		"ROLLBACK TO SAVEPOINT sp_2",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"SAVEPOINT sp_1",
+		"UPDATE accounts after",
+		"RELEASE SAVEPOINT sp_1",
+		"COMMIT",
+	}
+	if got := s.statements(); !slices.Equal(got, want) {
+		t.Fatalf("unexpected statements:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestInTxRetry(t *testing.T) {
+	ctx := context.Background()
+	tx, s := newTestTransactor()
+	deadlock := &mysql.MySQLError{Number: errDeadlock}
+
+	// 死锁时整体重试，达到次数上限后返回最后一次的错误
+	attempts := 0
+	err := tx.InTx(ctx, func(context.Context) error {
+		attempts++
+		return deadlock
+	})
+	if !errors.Is(err, deadlock) || !IsRetryable(err) || attempts != 3 {
+		t.Fatalf("expected 3 attempts ending with deadlock, got %d %v", attempts, err)
+	}
+	_ = s.statements()
+
+	// 锁等待超时后重试成功
+	attempts = 0
+	if err := tx.InTx(ctx, func(context.Context) error {
+		if attempts++; attempts == 1 {
+			return &mysql.MySQLError{Number: errLockWaitTimeout}
+		}
+		return nil
+	}); err != nil || attempts != 2 {
+		t.Fatalf("expected success on second attempt, got %d %v", attempts, err)
+	}
+	if got := s.statements(); !slices.Equal(got, []string{"BEGIN", "ROLLBACK", "BEGIN", "COMMIT"}) {
+		t.Fatalf("unexpected statements: %v", got)
+	}
+
+	// 不可重试的错误只执行一次
+	attempts = 0
+	_ = tx.InTx(ctx, func(context.Context) error {
+		attempts++
+		return &mysql.MySQLError{Number: 1062}
+	})
+	if attempts != 1 {
+		t.Fatalf("non-retryable error attempted %d times", attempts)
+	}
+
+	// 嵌套事务不重试
+	inner := 0
+	if err := tx.InTx(ctx, func(ctx context.Context) error {
+		if err := tx.InTx(ctx, func(context.Context) error {
+			inner++
+			return deadlock
+		}); !errors.Is(err, deadlock) {
+			t.Fatalf("expected nested deadlock, got %v", err)
+		}
+		return nil
+	}); err != nil || inner != 1 {
+		t.Fatalf("nested tx attempted %d times, err %v", inner, err)
+	}
+
+	// attempts <= 1 时不重试
+	tx, _ = newTestTransactor(WithTxRetry(1, 0))
+	attempts = 0
+	_ = tx.InTx(ctx, func(context.Context) error {
+		attempts++
+		return deadlock
+	})
+	if attempts != 1 {
+		t.Fatalf("retry disabled but attempted %d times", attempts)
+	}
+}
@@ -0,0 +1,421 @@
+package redisx
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound 数据不存在，loader 返回该错误时会写入空值缓存
+var ErrNotFound = errors.New("redisx: cache not found")
+
+// negativeValue 空值缓存在 Redis 中的占位值
+const negativeValue = "\x00nil"
+
+// Codec 缓存值的序列化方式
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// jsonCodec 默认的 JSON 序列化
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+/************************
+ * Option
+ ************************/
+
+// cacheOptions 缓存配置
+type cacheOptions struct {
+	prefix      string
+	codec       Codec
+	negativeTTL time.Duration
+	jitter      float64
+	localSize   int
+	localTTL    time.Duration
+}
+
+// CacheOption 缓存配置项函数
+type CacheOption func(*cacheOptions)
+
+// WithCachePrefix 设置缓存 key 的前缀，默认 cache:
+//
+// 同一个前缀的缓存共享本地缓存失效通知的频道。
+func WithCachePrefix(prefix string) CacheOption {
+	return func(o *cacheOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithCodec 设置序列化方式，默认 JSON
+func WithCodec(codec Codec) CacheOption {
+	return func(o *cacheOptions) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithNegativeTTL 设置空值缓存的过期时间，默认 1 分钟，<=0 表示不缓存空值
+func WithNegativeTTL(d time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.negativeTTL = d
+	}
+}
+
+// WithTTLJitter 设置过期时间的随机抖动比例，默认 0.1，即在 ttl 基础上随机增加 0 ~ 10%
+func WithTTLJitter(ratio float64) CacheOption {
+	return func(o *cacheOptions) {
+		if ratio >= 0 {
+			o.jitter = ratio
+		}
+	}
+}
+
+// WithLocalCache 启用进程内 LRU 本地缓存，size 为最大条目数，ttl 为本地缓存的最长有效期
+//
+// 本地缓存通过 Redis pub/sub 失效，Set、Delete 会通知所有实例删除本地副本。
+func WithLocalCache(size int, ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.localSize = size
+		o.localTTL = ttl
+	}
+}
+
+/************************
+ * Cache
+ ************************/
+
+// Cache 旁路缓存，读取时先查本地缓存和 Redis，未命中再调用 loader 加载并回填
+//
+// 并发加载同一个 key 时只会调用一次 loader，不存在的数据会缓存为空值，
+// 过期时间会随机增加一段时间，避免大量 key 同时过期。
+type Cache[T any] struct {
+	client redis.UniversalClient
+	log    *log.Helper
+	opts   *cacheOptions
+	group  singleflight.Group
+
+	id      string // 当前实例标识，忽略自己发出的失效通知
+	channel string
+	local   *lru[T]
+}
+
+// NewCache 创建旁路缓存，返回的 cleanup 用于停止本地缓存的失效订阅
+func NewCache[T any](logger log.Logger, client redis.UniversalClient, opts ...CacheOption) (*Cache[T], func()) {
+	o := &cacheOptions{
+		prefix:      "cache:",
+		codec:       jsonCodec{},
+		negativeTTL: time.Minute,
+		jitter:      0.1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := &Cache[T]{
+		client:  client,
+		log:     log.NewHelper(logger),
+		opts:    o,
+		id:      uuid.NewString(),
+		channel: o.prefix + "__invalidate",
+	}
+	if o.localSize <= 0 || o.localTTL <= 0 {
+		return c, func() {}
+	}
+
+	c.local = newLRU[T](o.localSize)
+	pubsub := client.Subscribe(context.Background(), c.channel)
+	go c.subscribe(pubsub)
+	return c, func() {
+		_ = pubsub.Close()
+	}
+}
+
+// Get 读取缓存，未命中或命中空值时返回 ErrNotFound
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	v, hit, err := c.get(ctx, key)
+	if err == nil && !hit {
+		return v, ErrNotFound
+	}
+	return v, err
+}
+
+// get 依次读取本地缓存和 Redis，hit 表示命中缓存（包括空值缓存，此时返回 ErrNotFound）
+func (c *Cache[T]) get(ctx context.Context, key string) (v T, hit bool, err error) {
+	if c.local != nil {
+		if e, ok := c.local.get(key); ok {
+			if e.negative {
+				return v, true, ErrNotFound
+			}
+			return e.value, true, nil
+		}
+	}
+
+	data, err := c.client.Get(ctx, c.opts.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+
+	if string(data) == negativeValue {
+		if c.local != nil {
+			c.local.set(key, lruEntry[T]{negative: true}, c.localTTL(c.opts.negativeTTL))
+		}
+		return v, true, ErrNotFound
+	}
+
+	if err := c.opts.codec.Unmarshal(data, &v); err != nil {
+		// 无法解析的值按未命中处理，GetOrLoad 会重新加载并覆盖
+		var zero T
+		return zero, false, err
+	}
+	if c.local != nil {
+		c.local.set(key, lruEntry[T]{value: v}, c.opts.localTTL)
+	}
+	return v, true, nil
+}
+
+// Set 写入缓存，并通知其他实例删除本地副本
+func (c *Cache[T]) Set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	if err := c.set(ctx, key, v, ttl); err != nil {
+		return err
+	}
+	c.publish(ctx, key)
+	return nil
+}
+
+// Delete 删除缓存，并通知所有实例删除本地副本
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = c.opts.prefix + key
+		if c.local != nil {
+			c.local.delete(key)
+		}
+	}
+	if err := c.client.Del(ctx, full...).Err(); err != nil {
+		return err
+	}
+	c.publish(ctx, keys...)
+	return nil
+}
+
+// GetOrLoad 读取缓存，未命中时调用 loader 加载并以 ttl 回填
+//
+// loader 返回 ErrNotFound 时写入空值缓存，其他错误不缓存。
+// 并发加载同一个 key 时只会调用一次 loader，loader 的 ctx 不受单个调用方取消的影响。
+// Redis 读写失败时降级为直接调用 loader。
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	v, hit, err := c.get(ctx, key)
+	if hit {
+		return v, err
+	}
+	if err != nil {
+		c.log.WithContext(ctx).Warnw("msg", "read cache failed", "key", key, "error", err)
+	}
+
+	ch := c.group.DoChan(key, func() (any, error) {
+		loadCtx := context.WithoutCancel(ctx)
+		v, err := loader(loadCtx)
+		switch {
+		case err == nil:
+			if e := c.set(loadCtx, key, v, ttl); e != nil {
+				c.log.WithContext(ctx).Warnw("msg", "write cache failed", "key", key, "error", e)
+			}
+		case errors.Is(err, ErrNotFound):
+			c.setNegative(loadCtx, key)
+		}
+		return v, err
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return zero, r.Err
+		}
+		// T 为接口类型且加载函数返回 nil 时 r.Val 为 nil，直接断言会 panic
+		v, _ := r.Val.(T)
+		return v, nil
+	}
+}
+
+// set 序列化后写入 Redis 和本地缓存
+func (c *Cache[T]) set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	data, err := c.opts.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ttl = c.jitter(ttl)
+	if err := c.client.Set(ctx, c.opts.prefix+key, data, ttl).Err(); err != nil {
+		return err
+	}
+	if c.local != nil {
+		c.local.set(key, lruEntry[T]{value: v}, c.localTTL(ttl))
+	}
+	return nil
+}
+
+// setNegative 写入空值缓存
+func (c *Cache[T]) setNegative(ctx context.Context, key string) {
+	if c.opts.negativeTTL <= 0 {
+		return
+	}
+	ttl := c.jitter(c.opts.negativeTTL)
+	if err := c.client.Set(ctx, c.opts.prefix+key, negativeValue, ttl).Err(); err != nil {
+		c.log.WithContext(ctx).Warnw("msg", "write negative cache failed", "key", key, "error", err)
+	}
+	if c.local != nil {
+		c.local.set(key, lruEntry[T]{negative: true}, c.localTTL(ttl))
+	}
+}
+
+// jitter 在 ttl 基础上随机增加 0 ~ jitter 比例的时间
+func (c *Cache[T]) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.opts.jitter <= 0 {
+		return ttl
+	}
+	n := time.Duration(float64(ttl) * c.opts.jitter)
+	if n <= 0 {
+		return ttl
+	}
+	return ttl + rand.N(n)
+}
+
+// localTTL 本地缓存的有效期不超过 Redis 中的剩余时间
+func (c *Cache[T]) localTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > c.opts.localTTL {
+		return c.opts.localTTL
+	}
+	return ttl
+}
+
+// publish 发送本地缓存失效通知，消息格式为 实例标识|key
+func (c *Cache[T]) publish(ctx context.Context, keys ...string) {
+	if c.local == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := c.client.Publish(ctx, c.channel, c.id+"|"+key).Err(); err != nil {
+			c.log.WithContext(ctx).Warnw("msg", "publish cache invalidation failed", "key", key, "error", err)
+		}
+	}
+}
+
+// subscribe 接收其他实例的失效通知并删除本地副本，订阅关闭后退出
+func (c *Cache[T]) subscribe(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		id, key, ok := strings.Cut(msg.Payload, "|")
+		if !ok || id == c.id {
+			continue
+		}
+		c.local.delete(key)
+	}
+}
+
+/************************
+ * LRU
+ ************************/
+
+// lruEntry 本地缓存条目，negative 表示空值缓存
+type lruEntry[T any] struct {
+	value    T
+	negative bool
+}
+
+// lruItem 链表节点
+type lruItem[T any] struct {
+	key      string
+	entry    lruEntry[T]
+	expireAt time.Time
+}
+
+// lru 带过期时间的并发安全 LRU
+type lru[T any] struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+func newLRU[T any](size int) *lru[T] {
+	return &lru[T]{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (l *lru[T]) get(key string) (lruEntry[T], bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return lruEntry[T]{}, false
+	}
+	item := el.Value.(*lruItem[T])
+	if time.Now().After(item.expireAt) {
+		l.ll.Remove(el)
+		delete(l.items, key)
+		return lruEntry[T]{}, false
+	}
+	l.ll.MoveToFront(el)
+	return item.entry, true
+}
+
+func (l *lru[T]) set(key string, entry lruEntry[T], ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expireAt := time.Now().Add(ttl)
+	if el, ok := l.items[key]; ok {
+		item := el.Value.(*lruItem[T])
+		item.entry, item.expireAt = entry, expireAt
+		l.ll.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.ll.PushFront(&lruItem[T]{key: key, entry: entry, expireAt: expireAt})
+	if l.ll.Len() > l.size {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem[T]).key)
+	}
+}
+
+func (l *lru[T]) delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.ll.Remove(el)
+		delete(l.items, key)
+	}
+}
@@ -0,0 +1,217 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type testUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestCache(t *testing.T, mr *miniredis.Miniredis, opts ...CacheOption) *Cache[testUser] {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	c, cleanup := NewCache[testUser](log.NewStdLogger(io.Discard), client, opts...)
+	t.Cleanup(func() {
+		cleanup()
+		_ = client.Close()
+	})
+	return c
+}
+
+func TestCacheGetOrLoadSingleflight(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c := newTestCache(t, mr)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(context.Context) (testUser, error) {
+		calls.Add(1)
+		<-release
+		return testUser{ID: 1, Name: "alice"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := c.GetOrLoad(ctx, "user:1", time.Minute, loader)
+			if err != nil || u.Name != "alice" {
+				t.Errorf("GetOrLoad() = %+v, %v", u, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+
+	// 过期时间在 [ttl, ttl*1.1) 之间
+	ttl := mr.TTL("cache:user:1")
+	if ttl < time.Minute || ttl >= time.Minute+6*time.Second {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+
+	u, err := c.GetOrLoad(ctx, "user:1", time.Minute, loader)
+	if err != nil || u.ID != 1 || calls.Load() != 1 {
+		t.Fatalf("expected cache hit, got %+v, %v, calls=%d", u, err, calls.Load())
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c := newTestCache(t, mr, WithNegativeTTL(time.Second), WithTTLJitter(0))
+
+	var calls int
+	loader := func(context.Context) (testUser, error) {
+		calls++
+		return testUser{}, ErrNotFound
+	}
+
+	for range 3 {
+		if _, err := c.GetOrLoad(ctx, "user:404", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader called %d times, want 1", calls)
+	}
+	if ttl := mr.TTL("cache:user:404"); ttl != time.Second {
+		t.Fatalf("negative ttl = %v, want 1s", ttl)
+	}
+
+	// 其他错误不缓存
+	boom := errors.New("boom")
+	for range 2 {
+		if _, err := c.GetOrLoad(ctx, "user:500", time.Minute, func(context.Context) (testUser, error) {
+			calls++
+			return testUser{}, boom
+		}); !errors.Is(err, boom) {
+			t.Fatalf("expected loader error, got %v", err)
+		}
+	}
+	if calls != 3 || mr.Exists("cache:user:500") {
+		t.Fatalf("loader errors must not be cached, calls=%d", calls)
+	}
+}
+
+func TestCacheCorruptValue(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c := newTestCache(t, mr)
+
+	if err := mr.Set("cache:user:1", "{not json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "user:1"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected decode error, got %v", err)
+	}
+
+	// 无法解析的值按未命中处理，重新加载并覆盖
+	u, err := c.GetOrLoad(ctx, "user:1", time.Minute, func(context.Context) (testUser, error) {
+		return testUser{ID: 1, Name: "alice"}, nil
+	})
+	if err != nil || u.Name != "alice" {
+		t.Fatalf("GetOrLoad() = %+v, %v", u, err)
+	}
+	if u, err := c.Get(ctx, "user:1"); err != nil || u.Name != "alice" {
+		t.Fatalf("expected reloaded value, got %+v, %v", u, err)
+	}
+}
+
+func TestCacheNilValue(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	c, cleanup := NewCache[any](log.NewStdLogger(io.Discard), client)
+	t.Cleanup(func() {
+		cleanup()
+		_ = client.Close()
+	})
+
+	// T 为接口类型时加载函数可以返回 nil
+	var calls int
+	loader := func(context.Context) (any, error) {
+		calls++
+		return nil, nil
+	}
+	for range 2 {
+		v, err := c.GetOrLoad(ctx, "config", time.Minute, loader)
+		if err != nil || v != nil {
+			t.Fatalf("GetOrLoad() = %v, %v, want nil", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader called %d times, want 1", calls)
+	}
+	if v, err := c.Get(ctx, "config"); err != nil || v != nil {
+		t.Fatalf("Get() = %v, %v, want nil", v, err)
+	}
+}
+
+func TestCacheLocalInvalidation(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	a := newTestCache(t, mr, WithLocalCache(10, time.Minute))
+	b := newTestCache(t, mr, WithLocalCache(10, time.Minute))
+	time.Sleep(20 * time.Millisecond) // 等待订阅生效
+
+	if err := a.Set(ctx, "user:1", testUser{ID: 1, Name: "alice"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if u, err := b.Get(ctx, "user:1"); err != nil || u.Name != "alice" {
+		t.Fatalf("Get() = %+v, %v", u, err)
+	}
+
+	// b 已有本地副本，直接修改 Redis 不会被 b 看到
+	mr.Set("cache:user:1", `{"id":1,"name":"bob"}`)
+	if u, _ := b.Get(ctx, "user:1"); u.Name != "alice" {
+		t.Fatalf("expected local hit, got %+v", u)
+	}
+
+	// a 更新后通知 b 删除本地副本
+	if err := a.Set(ctx, "user:1", testUser{ID: 1, Name: "carol"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		u, err := b.Get(ctx, "user:1")
+		if err == nil && u.Name == "carol" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("local cache was not invalidated, got %+v, %v", u, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := a.Delete(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for {
+		if _, err := b.Get(ctx, "user:1"); errors.Is(err, ErrNotFound) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("local cache was not invalidated after delete")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+package redisx
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewSentinelClient 创建 Sentinel 模式的 Redis 客户端
+//
+// source 为 sentinel 地址，多个地址可以用逗号分隔，也可以用 addr 参数追加，每个地址都需要带端口：
+//
+//	redis://10.0.0.1:26379,10.0.0.2:26379,10.0.0.3:26379/0?password=secret
+//	redis://10.0.0.1:26379/0?addr=10.0.0.2:26379&addr=10.0.0.3:26379&password=secret
+//
+// masterName 为空时使用 source 中的 master_name 参数。
+// URL 中的账号密码用于连接 sentinel，master 的账号密码使用 username、password 参数。
+func NewSentinelClient(logger log.Logger, masterName, source string, opts ...Option) (redis.UniversalClient, func(), error) {
+	l := log.NewHelper(logger)
+
+	expanded, err := expandHosts(source)
+	if err != nil {
+		l.Errorw("parse redis url failed", "error", err)
+		return nil, nil, err
+	}
+	opt, err := redis.ParseFailoverURL(expanded)
+	if err != nil {
+		l.Errorw("parse redis url failed", "error", err)
+		return nil, nil, err
+	}
+	if masterName != "" {
+		opt.MasterName = masterName
+	}
+
+	o := newOptions(opts...)
+
+	// 应用配置到 redis.FailoverOptions，go-redis 没有 MaxConcurrentDials 字段，该项不生效
+	opt.PoolSize = o.PoolSize
+	opt.MinIdleConns = o.MinIdleConns
+	opt.MaxIdleConns = o.MaxIdleConns
+	opt.MaxActiveConns = o.MaxActiveConns
+	opt.ConnMaxLifetime = o.ConnMaxLifetime
+	opt.ConnMaxIdleTime = o.ConnMaxIdleTime
+	opt.PoolTimeout = o.PoolTimeout
+
+	client := redis.NewFailoverClient(opt)
+
+	cleanup, err := connect(logger, client, "redis-sentinel@"+opt.MasterName, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, cleanup, nil
+}
+
+// NewClusterClient 创建 Cluster 模式的 Redis 客户端
+//
+// source 为集群节点地址，多个地址可以用逗号分隔，也可以用 addr 参数追加，每个地址都需要带端口：
+//
+//	redis://:password@10.0.0.1:6379,10.0.0.2:6379,10.0.0.3:6379
+//	redis://:password@10.0.0.1:6379?addr=10.0.0.2:6379&addr=10.0.0.3:6379
+//
+// 连接池配置对每个节点分别生效。
+func NewClusterClient(logger log.Logger, source string, opts ...Option) (redis.UniversalClient, func(), error) {
+	l := log.NewHelper(logger)
+
+	expanded, err := expandHosts(source)
+	if err != nil {
+		l.Errorw("parse redis url failed", "error", err)
+		return nil, nil, err
+	}
+	opt, err := redis.ParseClusterURL(expanded)
+	if err != nil {
+		l.Errorw("parse redis url failed", "error", err)
+		return nil, nil, err
+	}
+
+	o := newOptions(opts...)
+
+	// 应用配置到 redis.ClusterOptions，MaxConcurrentDials 同样不生效
+	opt.PoolSize = o.PoolSize
+	opt.MinIdleConns = o.MinIdleConns
+	opt.MaxIdleConns = o.MaxIdleConns
+	opt.MaxActiveConns = o.MaxActiveConns
+	opt.ConnMaxLifetime = o.ConnMaxLifetime
+	opt.ConnMaxIdleTime = o.ConnMaxIdleTime
+	opt.PoolTimeout = o.PoolTimeout
+
+	client := redis.NewClusterClient(opt)
+
+	cleanup, err := connect(logger, client, "redis-cluster@"+strings.Join(opt.Addrs, ","), o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, cleanup, nil
+}
+
+// expandHosts 把 host 中逗号分隔的多个地址改写为 go-redis 支持的 addr 参数
+//
+// 在 url.Parse 之前处理，因为多地址的 host 不是合法的 URL。
+func expandHosts(source string) (string, error) {
+	scheme, rest, ok := strings.Cut(source, "://")
+	if !ok {
+		return source, nil
+	}
+
+	end := strings.IndexAny(rest, "/?")
+	if end < 0 {
+		end = len(rest)
+	}
+	authority, tail := rest[:end], rest[end:]
+
+	userinfo, hostList := "", authority
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userinfo, hostList = authority[:i+1], authority[i+1:]
+	}
+	hosts := strings.Split(hostList, ",")
+	if len(hosts) == 1 {
+		return source, nil
+	}
+
+	u, err := url.Parse(scheme + "://" + userinfo + strings.TrimSpace(hosts[0]) + tail)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for _, h := range hosts[1:] {
+		if h = strings.TrimSpace(h); h != "" {
+			q.Add("addr", h)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
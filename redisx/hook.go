@@ -0,0 +1,198 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/metricsx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 链路追踪的 instrumentation 名称
+const tracerName = "github.com/lhlyu/kratos-easy/redisx"
+
+// redactedArg 隐藏后的参数占位符
+const redactedArg = "?"
+
+// commandDuration 每条命令的耗时，流水线按整体记录为 pipeline
+var commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metricsx.Namespace,
+	Name:      "redis_command_duration_seconds",
+	Help:      "Redis command latency in seconds by client, command and status.",
+	Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+}, []string{"redis_name", "command", "status"})
+
+func init() {
+	metricsx.Registry().MustRegister(commandDuration)
+}
+
+// hook go-redis 命令埋点：链路追踪、慢命令日志、耗时指标
+type hook struct {
+	logger        log.Logger
+	name          string
+	tracing       bool
+	metrics       bool
+	showArgs      bool
+	slowThreshold time.Duration
+}
+
+// newHook 根据配置创建埋点 hook
+func newHook(logger log.Logger, name string, o *options) *hook {
+	return &hook{
+		logger:        logger,
+		name:          name,
+		tracing:       o.EnableTracing,
+		metrics:       o.EnableMetrics,
+		showArgs:      o.ShowArgs,
+		slowThreshold: o.SlowThreshold,
+	}
+}
+
+func (h *hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		op := cmd.Name()
+		text := sync.OnceValue(func() string {
+			return h.format(cmd)
+		})
+
+		ctx, span := h.start(ctx, op, text)
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.finish(ctx, span, start, op, text, 1, err)
+		return err
+	}
+}
+
+func (h *hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		text := sync.OnceValue(func() string {
+			texts := make([]string, len(cmds))
+			for i, cmd := range cmds {
+				texts[i] = h.format(cmd)
+			}
+			return strings.Join(texts, "; ")
+		})
+
+		ctx, span := h.start(ctx, "pipeline", text)
+		start := time.Now()
+		err := next(ctx, cmds)
+		if err == nil {
+			// 流水线整体成功时，单条命令仍可能失败
+			for _, cmd := range cmds {
+				if e := cmd.Err(); e != nil && !errors.Is(e, redis.Nil) {
+					err = e
+					break
+				}
+			}
+		}
+		h.finish(ctx, span, start, "pipeline", text, len(cmds), err)
+		return err
+	}
+}
+
+// start 开始一个 span，未启用链路追踪时返回 nil
+//
+// text 只在需要时才格式化，避免关闭埋点时的额外开销。
+func (h *hook) start(ctx context.Context, op string, text func() string) (context.Context, trace.Span) {
+	if !h.tracing {
+		return ctx, nil
+	}
+	return otel.Tracer(tracerName).Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(text()),
+			attribute.String("db.redis.client", h.name),
+		),
+	)
+}
+
+// finish 结束 span 并记录指标和慢命令日志，redis.Nil 不视为错误
+func (h *hook) finish(ctx context.Context, span trace.Span, start time.Time, op string, text func() string, size int, err error) {
+	elapsed := time.Since(start)
+	failed := err != nil && !errors.Is(err, redis.Nil)
+
+	if span != nil {
+		if size > 1 {
+			span.SetAttributes(semconv.DBOperationBatchSize(size))
+		}
+		if failed {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	if h.metrics {
+		status := "ok"
+		if failed {
+			status = "error"
+		}
+		commandDuration.WithLabelValues(h.name, op, status).Observe(elapsed.Seconds())
+	}
+
+	if h.slowThreshold > 0 && elapsed >= h.slowThreshold {
+		kvs := []any{
+			"msg", "slow redis command",
+			"redis", h.name,
+			"cmd", text(),
+			"latency", elapsed.String(),
+		}
+		if failed {
+			kvs = append(kvs, "error", err)
+		}
+		_ = log.WithContext(ctx, h.logger).Log(log.LevelWarn, kvs...)
+	}
+}
+
+// keylessCommands 第一个参数不是 key 的命令，参数全部隐藏，避免泄露密码或脚本
+var keylessCommands = map[string]bool{
+	"acl": true, "auth": true, "client": true, "config": true, "echo": true,
+	"eval": true, "eval_ro": true, "evalsha": true, "evalsha_ro": true,
+	"fcall": true, "fcall_ro": true, "function": true, "hello": true,
+	"info": true, "migrate": true, "ping": true, "script": true, "select": true,
+}
+
+// format 返回命令文本，默认只保留命令名和 key，其余参数替换为 ?
+func (h *hook) format(cmd redis.Cmder) string {
+	args := cmd.Args()
+	showKey := !keylessCommands[cmd.Name()]
+
+	parts := make([]string, 0, len(args))
+	for i, arg := range args {
+		if h.showArgs || i == 0 || i == 1 && showKey {
+			parts = append(parts, toString(arg))
+			continue
+		}
+		parts = append(parts, redactedArg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// toString 把命令参数转成字符串
+func toString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,214 @@
+package redisx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHookFormat(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name     string
+		args     []any
+		showArgs bool
+		want     string
+	}{
+		{"command only", []any{"ping"}, false, "ping"},
+		{"first key", []any{"set", "user:1", "secret", "ex", 60}, false, "set user:1 ? ? ?"},
+		{"only first key", []any{"mget", "a", "b"}, false, "mget a ?"},
+		{"bytes key", []any{"get", []byte("user:1")}, false, "get user:1"},
+		{"upper case", []any{"AUTH", "default", "pw"}, false, "AUTH ? ?"},
+		{"auth", []any{"auth", "pw"}, false, "auth ?"},
+		{"eval", []any{"eval", "return 1", 1, "k"}, false, "eval ? ? ?"},
+		{"hello", []any{"hello", 3, "auth", "u", "pw"}, false, "hello ? ? ? ?"},
+		{"show args", []any{"set", "user:1", "secret", "ex", 60}, true, "set user:1 secret ex 60"},
+		{"show args keyless", []any{"auth", "pw"}, true, "auth pw"},
+	}
+	for _, c := range cases {
+		h := &hook{showArgs: c.showArgs}
+		if got := h.format(redis.NewCmd(ctx, c.args...)); got != c.want {
+			t.Errorf("%s: format() = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+// newHookClient 返回挂载了埋点 hook 的 miniredis 客户端和 span 记录器
+//
+// 先建立连接，握手命令不计入记录。
+func newHookClient(t *testing.T, name string, buf *bytes.Buffer, o *options) (*redis.Client, *tracetest.SpanRecorder) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.AddHook(newHook(log.NewStdLogger(buf), name, o))
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return client, rec
+}
+
+// commandCount 返回命令耗时指标的样本数
+func commandCount(t *testing.T, name, command, status string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := commandDuration.WithLabelValues(name, command, status).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestHookCommand(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	client, rec := newHookClient(t, "redis@hook/cmd", buf, &options{
+		EnableTracing: true,
+		EnableMetrics: true,
+		SlowThreshold: time.Hour,
+	})
+
+	name := "redis@hook/cmd"
+	set, get, incr := commandCount(t, name, "set", "ok"), commandCount(t, name, "get", "ok"), commandCount(t, name, "incr", "error")
+
+	if err := client.Set(ctx, "user:1", "secret", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get(ctx, "missing").Err(); !errors.Is(err, redis.Nil) {
+		t.Fatal(err)
+	}
+	if err := client.Incr(ctx, "user:1").Err(); err == nil {
+		t.Fatal("expected incr on a string to fail")
+	}
+
+	// 每条命令一个 span，redis.Nil 不视为错误
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	want := []struct {
+		name  string
+		query string
+		code  otelcodes.Code
+	}{
+		{"set", "set user:1 ?", otelcodes.Unset},
+		{"get", "get missing", otelcodes.Unset},
+		{"incr", "incr user:1", otelcodes.Error},
+	}
+	for i, w := range want {
+		s := spans[i]
+		if s.Name() != w.name || s.Status().Code != w.code || s.SpanKind() != trace.SpanKindClient {
+			t.Errorf("span %d: name=%s status=%v kind=%v", i, s.Name(), s.Status(), s.SpanKind())
+		}
+		if got := spanAttr(s, "db.query.text"); got != w.query {
+			t.Errorf("span %d: query = %q, want %q", i, got, w.query)
+		}
+	}
+	if len(spans[2].Events()) == 0 {
+		t.Error("expected error to be recorded on the span")
+	}
+
+	if commandCount(t, name, "set", "ok") != set+1 ||
+		commandCount(t, name, "get", "ok") != get+1 ||
+		commandCount(t, name, "incr", "error") != incr+1 {
+		t.Fatal("unexpected command duration samples")
+	}
+	if n := testutil.CollectAndCount(commandDuration, "kratos_redis_command_duration_seconds"); n < 3 {
+		t.Fatalf("collected %d series, want at least 3", n)
+	}
+
+	// 未超过阈值不输出慢命令日志
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+}
+
+func TestHookPipelineAndSlowLog(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	client, rec := newHookClient(t, "redis@hook/pipe", buf, &options{
+		EnableTracing: true,
+		EnableMetrics: true,
+		SlowThreshold: time.Nanosecond,
+	})
+
+	before := commandCount(t, "redis@hook/pipe", "pipeline", "error")
+
+	// 流水线整体一个 span，其中任意命令失败都记为错误
+	_, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "k", "secret", 0)
+		p.Incr(ctx, "k")
+		p.Get(ctx, "k")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected pipeline error")
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "pipeline" || s.Status().Code != otelcodes.Error {
+		t.Fatalf("unexpected span: %s %v", s.Name(), s.Status())
+	}
+	if got := spanAttr(s, "db.operation.batch.size"); got != "3" {
+		t.Fatalf("batch size = %q", got)
+	}
+	if got := spanAttr(s, "db.query.text"); got != "set k ?; incr k; get k" {
+		t.Fatalf("query = %q", got)
+	}
+	if commandCount(t, "redis@hook/pipe", "pipeline", "error") != before+1 {
+		t.Fatal("expected pipeline error sample")
+	}
+
+	// 超过阈值输出慢命令日志，参数已隐藏
+	out := buf.String()
+	if !strings.Contains(out, "slow redis command") || !strings.Contains(out, "set k ?; incr k; get k") || strings.Contains(out, "secret") {
+		t.Fatalf("unexpected slow log: %s", out)
+	}
+}
+
+func TestHookDisabled(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	client, rec := newHookClient(t, "redis@hook/off", buf, &options{})
+	before := commandCount(t, "redis@hook/off", "set", "ok")
+
+	if err := client.Set(ctx, "k", "v", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Ended()) != 0 || buf.Len() != 0 || commandCount(t, "redis@hook/off", "set", "ok") != before {
+		t.Fatal("expected no spans, logs or samples when instrumentation is disabled")
+	}
+}
+
+func spanAttr(s sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotObtained 锁已被其他持有者占用
+	ErrNotObtained = errors.New("redisx: lock not obtained")
+	// ErrLockNotHeld 释放时锁已过期或被其他持有者占用
+	ErrLockNotHeld = errors.New("redisx: lock not held")
+	// ErrLockLost 续期失败，锁已丢失
+	ErrLockLost = errors.New("redisx: lock lost")
+)
+
+// acquireScript 加锁成功后递增 fencing token，锁 key 和 token key 使用相同的 hash tag
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// releaseScript 只有持有者才能释放
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// renewScript 只有持有者才能续期
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+/************************
+ * Option
+ ************************/
+
+// lockOptions 分布式锁配置
+type lockOptions struct {
+	prefix   string
+	ttl      time.Duration
+	minDelay time.Duration
+	maxDelay time.Duration
+	renew    bool
+}
+
+// LockOption 分布式锁配置项函数
+type LockOption func(*lockOptions)
+
+// WithLockPrefix 设置锁 key 的前缀，默认 lock:
+func WithLockPrefix(prefix string) LockOption {
+	return func(o *lockOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithLockTTL 设置锁的租约时间，默认 10 秒
+func WithLockTTL(d time.Duration) LockOption {
+	return func(o *lockOptions) {
+		if d > 0 {
+			o.ttl = d
+		}
+	}
+}
+
+// WithLockBackoff 设置 Lock 阻塞等待时的重试间隔，从 min 开始指数增长到 max，默认 10ms ~ 500ms
+func WithLockBackoff(min, max time.Duration) LockOption {
+	return func(o *lockOptions) {
+		if min > 0 {
+			o.minDelay = min
+		}
+		if max >= o.minDelay {
+			o.maxDelay = max
+		}
+	}
+}
+
+// WithDisableRenew 禁止自动续期，锁在租约到期后自动释放
+func WithDisableRenew() LockOption {
+	return func(o *lockOptions) {
+		o.renew = false
+	}
+}
+
+/************************
+ * Locker
+ ************************/
+
+// Locker 基于 Redis 的分布式锁
+//
+// 使用 SET NX PX 加锁，每次加锁成功都会得到一个单调递增的 fencing token，
+// 下游可以拒绝 token 更小的写入，避免锁过期后旧持有者的写入覆盖新持有者。
+type Locker struct {
+	client redis.UniversalClient
+	opts   *lockOptions
+}
+
+// NewLocker 创建分布式锁
+func NewLocker(client redis.UniversalClient, opts ...LockOption) *Locker {
+	o := &lockOptions{
+		prefix:   "lock:",
+		ttl:      10 * time.Second,
+		minDelay: 10 * time.Millisecond,
+		maxDelay: 500 * time.Millisecond,
+		renew:    true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Locker{client: client, opts: o}
+}
+
+// TryLock 尝试加锁，锁被占用时立即返回 ErrNotObtained
+//
+// 加锁成功后，在 ctx 未结束且未释放前会自动续期。
+func (l *Locker) TryLock(ctx context.Context, key string) (*Lock, error) {
+	// 锁 key 和 token key 使用相同的 hash tag，保证 Cluster 模式下落在同一个 slot
+	lockKey := l.opts.prefix + "{" + key + "}"
+	fenceKey := lockKey + ":fence"
+	token := uuid.NewString()
+
+	// 租约从发出请求前开始计算，保证本地估计的到期时间不晚于 Redis
+	start := time.Now()
+	fence, err := acquireScript.Run(ctx, l.client, []string{lockKey, fenceKey}, token, l.opts.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if fence == 0 {
+		return nil, ErrNotObtained
+	}
+
+	lk := &Lock{
+		locker: l,
+		key:    lockKey,
+		token:  token,
+		fence:  fence,
+		done:   make(chan struct{}),
+	}
+	lk.ctx, lk.cancel = context.WithCancelCause(ctx)
+
+	if l.opts.renew {
+		go lk.keepAlive(start)
+	} else {
+		close(lk.done)
+	}
+	return lk, nil
+}
+
+// Lock 阻塞加锁，锁被占用时按指数退避重试，直到加锁成功或 ctx 结束
+func (l *Locker) Lock(ctx context.Context, key string) (*Lock, error) {
+	delay := l.opts.minDelay
+	for {
+		lk, err := l.TryLock(ctx, key)
+		if !errors.Is(err, ErrNotObtained) {
+			return lk, err
+		}
+
+		// 随机抖动，避免多个等待者同时重试
+		wait := delay/2 + rand.N(delay/2+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, l.opts.maxDelay)
+	}
+}
+
+/************************
+ * Lock
+ ************************/
+
+// Lock 已获得的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+	fence  int64
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	done   chan struct{} // 续期协程退出后关闭
+
+	once sync.Once
+}
+
+// Key 返回锁在 Redis 中的 key
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者的随机标识
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Fence 返回本次加锁的 fencing token，同一个 key 每次加锁单调递增
+func (lk *Lock) Fence() int64 {
+	return lk.fence
+}
+
+// Context 返回持有锁期间有效的 context，锁丢失或释放后结束
+//
+// 锁丢失时 context.Cause 返回 ErrLockLost。
+func (lk *Lock) Context() context.Context {
+	return lk.ctx
+}
+
+// Unlock 释放锁并停止续期，锁已过期或被其他持有者占用时返回 ErrLockNotHeld
+func (lk *Lock) Unlock(ctx context.Context) error {
+	lk.once.Do(func() {
+		lk.cancel(context.Canceled)
+	})
+	<-lk.done
+
+	n, err := releaseScript.Run(ctx, lk.locker.client, []string{lk.key}, lk.token).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// keepAlive 每 1/3 租约续期一次，持有者 ctx 结束或释放后退出
+//
+// 续期被拒绝，或距上次成功续期超过 2/3 租约仍未续上时，视为锁已丢失。
+// 预留 1/3 租约作为安全余量，保证持有者的 context 在 Redis 中的 key 过期、锁被其他人获得之前结束。
+func (lk *Lock) keepAlive(lastRenew time.Time) {
+	defer close(lk.done)
+
+	ttl := lk.locker.opts.ttl
+	safe := ttl - ttl/3
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// 单次续期最多等到安全期限
+		start := time.Now()
+		timeout := min(ttl/3, time.Until(lastRenew.Add(safe)))
+		ctx, cancel := context.WithTimeout(lk.ctx, timeout)
+		n, err := renewScript.Run(ctx, lk.locker.client, []string{lk.key}, lk.token, ttl.Milliseconds()).Int64()
+		cancel()
+
+		switch {
+		case err == nil && n == 1:
+			lastRenew = start
+		case err == nil, time.Since(lastRenew) >= safe:
+			lk.cancel(ErrLockLost)
+			return
+		}
+	}
+}
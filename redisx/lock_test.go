@@ -0,0 +1,191 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLocker(t *testing.T, opts ...LockOption) (*miniredis.Miniredis, *Locker) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, NewLocker(client, opts...)
+}
+
+func TestLockerTryLock(t *testing.T) {
+	ctx := context.Background()
+	mr, locker := newTestLocker(t)
+
+	lk, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lk.Key() != "lock:{job}" || lk.Fence() != 1 {
+		t.Fatalf("unexpected lock: key=%s fence=%d", lk.Key(), lk.Fence())
+	}
+	if v, _ := mr.Get(lk.Key()); v != lk.Token() {
+		t.Fatalf("lock value = %q, want token %q", v, lk.Token())
+	}
+
+	if _, err := locker.TryLock(ctx, "job"); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("expected ErrNotObtained, got %v", err)
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if lk.Context().Err() == nil {
+		t.Fatal("lock context should be done after unlock")
+	}
+	if err := lk.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected ErrLockNotHeld on second unlock, got %v", err)
+	}
+
+	next, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Unlock(ctx)
+	if next.Fence() <= lk.Fence() {
+		t.Fatalf("fencing token must increase: %d <= %d", next.Fence(), lk.Fence())
+	}
+}
+
+func TestLockerUnlockOtherHolder(t *testing.T) {
+	ctx := context.Background()
+	mr, locker := newTestLocker(t, WithDisableRenew())
+
+	lk, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 租约到期后被其他持有者占用，旧持有者不能释放
+	mr.FastForward(11 * time.Second)
+	other, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lk.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("expected ErrLockNotHeld, got %v", err)
+	}
+	if !mr.Exists(other.Key()) {
+		t.Fatal("lock of the new holder must not be released")
+	}
+}
+
+func TestLockerLockWaits(t *testing.T) {
+	ctx := context.Background()
+	_, locker := newTestLocker(t, WithLockBackoff(time.Millisecond, 5*time.Millisecond))
+
+	lk, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Lock(timeout, "job"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	acquired := make(chan *Lock)
+	go func() {
+		next, err := locker.Lock(ctx, "job")
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- next
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case next := <-acquired:
+		if next != nil {
+			_ = next.Unlock(ctx)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not acquire the lock after unlock")
+	}
+}
+
+func TestLockerRenew(t *testing.T) {
+	ctx := context.Background()
+	mr, locker := newTestLocker(t, WithLockTTL(60*time.Millisecond))
+
+	lk, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// miniredis 的 TTL 不随真实时间减少，先改小再等待续期恢复
+	mr.SetTTL(lk.Key(), time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for mr.TTL(lk.Key()) != 60*time.Millisecond {
+		if time.Now().After(deadline) {
+			t.Fatal("lease was not renewed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 锁被删除后续期失败，持有者的 context 以 ErrLockLost 结束
+	mr.Del(lk.Key())
+	select {
+	case <-lk.Context().Done():
+		if !errors.Is(context.Cause(lk.Context()), ErrLockLost) {
+			t.Fatalf("expected ErrLockLost, got %v", context.Cause(lk.Context()))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock loss was not detected")
+	}
+}
+
+func TestLockerLostBeforeExpiry(t *testing.T) {
+	ctx := context.Background()
+	ttl := 300 * time.Millisecond
+	mr, locker := newTestLocker(t, WithLockTTL(ttl))
+
+	start := time.Now()
+	lk, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Redis 不可用时续期失败，持有者必须在租约到期前停止
+	mr.SetError("ERR unavailable")
+	select {
+	case <-lk.Context().Done():
+		if elapsed := time.Since(start); elapsed >= ttl {
+			t.Fatalf("lock loss detected after %s, lease is %s", elapsed, ttl)
+		}
+		if !errors.Is(context.Cause(lk.Context()), ErrLockLost) {
+			t.Fatalf("expected ErrLockLost, got %v", context.Cause(lk.Context()))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock loss was not detected")
+	}
+
+	// 租约到期后其他持有者获得锁时，旧持有者的 context 早已结束
+	mr.SetError("")
+	if _, err := locker.TryLock(ctx, "job"); !errors.Is(err, ErrNotObtained) {
+		t.Fatalf("lease should still be held in redis, got %v", err)
+	}
+	mr.FastForward(ttl)
+	next, err := locker.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Unlock(ctx)
+	if lk.Context().Err() == nil || next.Fence() <= lk.Fence() {
+		t.Fatal("old holder must be stopped before the lock is granted again")
+	}
+}
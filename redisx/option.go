@@ -12,6 +12,7 @@ type options struct {
 	// MaxConcurrentDials 表示同时创建连接的最大 goroutine 数。
 	// 如果 <=0，则默认等于 PoolSize。
 	// 如果大于 PoolSize，则上限为 PoolSize。
+	// 只对 NewClient 创建的单机客户端生效，go-redis 的 FailoverOptions、ClusterOptions 没有对应字段。
 	MaxConcurrentDials int
 
 	// PoolTimeout 表示获取连接时等待的最大时间，如果超时返回错误。
@@ -38,11 +39,54 @@ type options struct {
 	// ConnMaxLifetime 表示单个连接允许存在的最大时间。
 	// <=0 表示不关闭
 	ConnMaxLifetime time.Duration
+
+	// EnableHealth 表示是否注册到 healthx 就绪检查。
+	// 默认：true
+	EnableHealth bool
+
+	// EnableMetrics 表示是否注册连接池和命令耗时指标到 metricsx。
+	// 默认：true
+	EnableMetrics bool
+
+	// EnableTracing 表示是否为每条命令和流水线生成链路追踪 span。
+	// 默认：true
+	EnableTracing bool
+
+	// SlowThreshold 表示慢命令阈值，超过阈值的命令以 Warn 等级输出日志。
+	// <=0 表示不输出
+	// 默认：100 毫秒
+	SlowThreshold time.Duration
+
+	// ShowArgs 表示是否在 span 和日志中输出完整参数。
+	// 默认：false，只保留命令名和 key
+	ShowArgs bool
 }
 
 // Option redis 配置项函数
 type Option func(*options)
 
+// newOptions 创建并初始化 redis 配置
+func newOptions(opts ...Option) *options {
+	o := &options{
+		PoolSize:           10,
+		MinIdleConns:       5,
+		MaxIdleConns:       10,
+		MaxActiveConns:     0,
+		ConnMaxLifetime:    30 * time.Minute,
+		ConnMaxIdleTime:    10 * time.Minute,
+		PoolTimeout:        5 * time.Second,
+		MaxConcurrentDials: 10,
+		EnableHealth:       true,
+		EnableMetrics:      true,
+		EnableTracing:      true,
+		SlowThreshold:      100 * time.Millisecond,
+	}
+	for _, apply := range opts {
+		apply(o)
+	}
+	return o
+}
+
 // WithPoolSize 设置连接池基础连接数
 func WithPoolSize(n int) Option {
 	return func(o *options) {
@@ -53,6 +97,9 @@ func WithPoolSize(n int) Option {
 }
 
 // WithMaxConcurrentDials 设置最大并发创建连接数
+//
+// 只对 NewClient 创建的单机客户端生效，Sentinel 和 Cluster 模式下忽略，
+// 连接池预设中的 MaxConcurrentDials 同理。
 func WithMaxConcurrentDials(n int) Option {
 	return func(o *options) {
 		if n > 0 {
@@ -115,6 +162,41 @@ func WithConnMaxLifetime(d time.Duration) Option {
 	}
 }
 
+// WithDisableHealthCheck 禁止把客户端注册到 healthx 就绪检查
+func WithDisableHealthCheck() Option {
+	return func(o *options) {
+		o.EnableHealth = false
+	}
+}
+
+// WithDisableMetrics 禁止把连接池和命令耗时指标注册到 metricsx
+func WithDisableMetrics() Option {
+	return func(o *options) {
+		o.EnableMetrics = false
+	}
+}
+
+// WithDisableTracing 禁止为每条命令和流水线生成链路追踪 span
+func WithDisableTracing() Option {
+	return func(o *options) {
+		o.EnableTracing = false
+	}
+}
+
+// WithSlowThreshold 设置慢命令阈值（<=0 表示不输出慢命令日志）
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithShowArgs 在 span 和慢命令日志中输出完整参数，默认只保留命令名和 key
+func WithShowArgs() Option {
+	return func(o *options) {
+		o.ShowArgs = true
+	}
+}
+
 // WithSmallConfig 小型服务默认配置
 func WithSmallConfig() Option {
 	return func(o *options) {
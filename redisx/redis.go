@@ -2,9 +2,12 @@ package redisx
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lhlyu/kratos-easy/healthx"
+	"github.com/lhlyu/kratos-easy/metricsx"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -20,20 +23,7 @@ func NewClient(logger log.Logger, source string, opts ...Option) (*redis.Client,
 		return nil, nil, err
 	}
 
-	o := &options{
-		PoolSize:           10,
-		MinIdleConns:       5,
-		MaxIdleConns:       10,
-		MaxActiveConns:     0,
-		ConnMaxLifetime:    30 * time.Minute,
-		ConnMaxIdleTime:    10 * time.Minute,
-		PoolTimeout:        5 * time.Second,
-		MaxConcurrentDials: 10,
-	}
-
-	for _, apply := range opts {
-		apply(o)
-	}
+	o := newOptions(opts...)
 
 	// 应用配置到 redis.Options
 	opt.PoolSize = o.PoolSize
@@ -47,22 +37,51 @@ func NewClient(logger log.Logger, source string, opts ...Option) (*redis.Client,
 
 	client := redis.NewClient(opt)
 
+	cleanup, err := connect(logger, client, "redis@"+opt.Addr+"/"+strconv.Itoa(opt.DB), o)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, cleanup, nil
+}
+
+// connect 启动时 ping，注册健康检查和连接池指标，返回清理函数
+func connect(logger log.Logger, client redis.UniversalClient, name string, o *options) (func(), error) {
+	l := log.NewHelper(logger)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		_ = client.Close()
 		l.Errorw("ping redis failed", "error", err)
-		return nil, nil, err
+		return nil, err
 	}
 
 	l.Infow("redis connected")
 
+	client.AddHook(newHook(logger, name, o))
+
+	// 注册到就绪检查
+	unregisterHealth := func() {}
+	if o.EnableHealth {
+		unregisterHealth = healthx.Register(name, func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		})
+	}
+
+	// 注册连接池指标
+	unregisterMetrics := func() {}
+	if o.EnableMetrics {
+		unregisterMetrics = metricsx.RegisterRedis(name, client)
+	}
+
 	cleanup := func() {
+		unregisterHealth()
+		unregisterMetrics()
 		if err := client.Close(); err != nil {
 			l.Errorw("close redis failed", "error", err)
 		}
 	}
 
-	return client, cleanup, nil
+	return cleanup, nil
 }
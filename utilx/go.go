@@ -8,6 +8,15 @@ import (
 
 const panicBufSize = 64 << 10 // 64KB
 
+// Stack 返回当前 goroutine 的堆栈，超过 64KB 的部分会被截断。
+//
+// 在 recover 所在的 defer 中调用时，堆栈包含引发 panic 的位置。
+func Stack() []byte {
+	buf := make([]byte, panicBufSize)
+	n := runtime.Stack(buf, false)
+	return buf[:n]
+}
+
 // Parallel 并发执行所有传入的处理函数，并等待它们全部完成。
 //
 // 如果任意处理函数返回非 nil 的 error，或在执行过程中发生 panic，
@@ -37,13 +46,12 @@ func Parallel(handlers ...func() error) error {
 			defer wg.Done()
 			defer func() {
 				if r := recover(); r != nil {
-					buf := make([]byte, panicBufSize)
-					n := runtime.Stack(buf, false)
+					stack := Stack()
 					once.Do(func() {
 						err = fmt.Errorf(
 							"panic in parallel handler: %v\n%s",
 							r,
-							buf[:n],
+							stack,
 						)
 					})
 				}